@@ -0,0 +1,67 @@
+package analytics
+
+import "math"
+
+// Market is the carry assumed when pricing options on an index: a
+// continuously compounded risk-free rate and dividend yield, both annual
+// decimals (0.065 is 6.5%).
+type Market struct {
+	Rate     float64
+	DivYield float64
+}
+
+// Forward is the index forward for t years out.
+func (m Market) Forward(spot, t float64) float64 {
+	return spot * math.Exp((m.Rate-m.DivYield)*t)
+}
+
+// Discount is the risk-free discount factor for t years out.
+func (m Market) Discount(t float64) float64 {
+	return math.Exp(-m.Rate * t)
+}
+
+// secondsPerYear sets the day count: calendar time, 365 days a year.
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// YearFraction converts seconds to expiry into years.
+func YearFraction(seconds float64) float64 {
+	return seconds / secondsPerYear
+}
+
+// Black76 prices a European option on a forward. With t or vol at zero it
+// returns discounted intrinsic value.
+func Black76(forward, strike, t, vol, discount float64, call bool) float64 {
+	if t <= 0 || vol <= 0 {
+		if call {
+			return discount * math.Max(forward-strike, 0)
+		}
+		return discount * math.Max(strike-forward, 0)
+	}
+
+	d1, d2 := d1d2(forward, strike, t, vol)
+
+	if call {
+		return discount * (forward*normCDF(d1) - strike*normCDF(d2))
+	}
+	return discount * (strike*normCDF(-d2) - forward*normCDF(-d1))
+}
+
+// BlackScholes prices a European option on the spot index, through the
+// forward implied by m.
+func BlackScholes(spot, strike, t, vol float64, call bool, m Market) float64 {
+	return Black76(m.Forward(spot, t), strike, t, vol, m.Discount(t), call)
+}
+
+func d1d2(forward, strike, t, vol float64) (float64, float64) {
+	sd := vol * math.Sqrt(t)
+	d1 := (math.Log(forward/strike) + 0.5*sd*sd) / sd
+	return d1, d1 - sd
+}
+
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normPDF(x float64) float64 {
+	return math.Exp(-0.5*x*x) / math.Sqrt(2*math.Pi)
+}
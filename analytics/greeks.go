@@ -0,0 +1,53 @@
+package analytics
+
+import "math"
+
+// Greeks of a Black-Scholes option on the spot index. Vega is per one
+// volatility point (0.01) and theta per calendar day, both in premium.
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+}
+
+// BlackScholesGreeks returns the greeks at volatility vol, t years before
+// expiry. All greeks are NaN when vol or t is not positive.
+func BlackScholesGreeks(spot, strike, t, vol float64, call bool, m Market) Greeks {
+	if !(t > 0) || !(vol > 0) {
+		nan := math.NaN()
+		return Greeks{Delta: nan, Gamma: nan, Vega: nan, Theta: nan}
+	}
+
+	forward := m.Forward(spot, t)
+	d1, d2 := d1d2(forward, strike, t, vol)
+
+	sqrtT := math.Sqrt(t)
+	divDiscount := math.Exp(-m.DivYield * t)
+	rateDiscount := m.Discount(t)
+	density := normPDF(d1)
+
+	g := Greeks{
+		Gamma: divDiscount * density / (spot * vol * sqrtT),
+		Vega:  spot * divDiscount * density * sqrtT / 100,
+	}
+
+	// time decay of the volatility part, shared by calls and puts
+	decay := -spot * divDiscount * density * vol / (2 * sqrtT)
+
+	if call {
+		g.Delta = divDiscount * normCDF(d1)
+		g.Theta = decay -
+			m.Rate*strike*rateDiscount*normCDF(d2) +
+			m.DivYield*spot*divDiscount*normCDF(d1)
+	} else {
+		g.Delta = -divDiscount * normCDF(-d1)
+		g.Theta = decay +
+			m.Rate*strike*rateDiscount*normCDF(-d2) -
+			m.DivYield*spot*divDiscount*normCDF(-d1)
+	}
+
+	g.Theta /= 365
+
+	return g
+}
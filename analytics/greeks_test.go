@@ -0,0 +1,127 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBlackScholesGreeks(t *testing.T) {
+	// Hull's delta/gamma/vega/theta example: S 49, K 50, r 5%, 20 weeks,
+	// 20% vol. Vega is per vol point and theta per calendar day.
+	got := BlackScholesGreeks(49, 50, 20.0/52, 0.2, true, Market{Rate: 0.05})
+	want := Greeks{
+		Delta: 0.5216046610663964,
+		Gamma: 0.06554403934784439,
+		Vega:  0.12105479882628802,
+		Theta: -0.01179542417241801,
+	}
+
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"delta", got.Delta, want.Delta},
+		{"gamma", got.Gamma, want.Gamma},
+		{"vega", got.Vega, want.Vega},
+		{"theta", got.Theta, want.Theta},
+	} {
+		if math.Abs(c.got-c.want) > 1e-9 {
+			t.Errorf("%s %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+// The greeks must match finite differences of the premium, with carry.
+func TestBlackScholesGreeksDifferences(t *testing.T) {
+	m := Market{Rate: 0.065, DivYield: 0.012}
+
+	tests := []struct {
+		name   string
+		strike float64
+		call   bool
+	}{
+		{"atm call", 24000, true},
+		{"atm put", 24000, false},
+		{"itm call", 23000, true},
+		{"otm put", 23000, false},
+		{"otm call", 25500, true},
+		{"itm put", 25500, false},
+	}
+
+	const spot, tYear, vol = 24000.0, 21.0 / 365, 0.14
+	const dS, dVol, dT = 1.0, 1e-4, 1e-6
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := func(s, t, v float64) float64 {
+				return BlackScholes(s, tt.strike, t, v, tt.call, m)
+			}
+			g := BlackScholesGreeks(spot, tt.strike, tYear, vol, tt.call, m)
+
+			delta := (price(spot+dS, tYear, vol) - price(spot-dS, tYear, vol)) / (2 * dS)
+			gamma := (price(spot+dS, tYear, vol) - 2*price(spot, tYear, vol) + price(spot-dS, tYear, vol)) / (dS * dS)
+			vega := (price(spot, tYear, vol+dVol) - price(spot, tYear, vol-dVol)) / (2 * dVol) / 100
+			theta := (price(spot, tYear-dT, vol) - price(spot, tYear+dT, vol)) / (2 * dT) / 365
+
+			for _, c := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"delta", g.Delta, delta},
+				{"gamma", g.Gamma, gamma},
+				{"vega", g.Vega, vega},
+				{"theta", g.Theta, theta},
+			} {
+				if math.Abs(c.got-c.want) > 1e-5*math.Max(1, math.Abs(c.want)) {
+					t.Errorf("%s %v, want %v", c.name, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBlackScholesGreeksBounds(t *testing.T) {
+	m := Market{Rate: 0.065, DivYield: 0.012}
+	tYear := 30.0 / 365
+	divDiscount := math.Exp(-m.DivYield * tYear)
+
+	tests := []struct {
+		name   string
+		strike float64
+		call   bool
+		delta  float64
+	}{
+		{"deep itm call", 12000, true, divDiscount},
+		{"deep otm call", 48000, true, 0},
+		{"deep itm put", 48000, false, -divDiscount},
+		{"deep otm put", 12000, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := BlackScholesGreeks(24000, tt.strike, tYear, 0.2, tt.call, m)
+			if math.Abs(g.Delta-tt.delta) > 1e-9 {
+				t.Errorf("delta %v, want %v", g.Delta, tt.delta)
+			}
+			if g.Gamma > 1e-9 || g.Vega > 1e-9 {
+				t.Errorf("gamma %v, vega %v, want 0", g.Gamma, g.Vega)
+			}
+		})
+	}
+}
+
+func TestBlackScholesGreeksUndefined(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		t, vol float64
+	}{
+		{"at expiry", 0, 0.2},
+		{"zero vol", 0.1, 0},
+		{"nan vol", 0.1, math.NaN()},
+	} {
+		g := BlackScholesGreeks(24000, 24000, c.t, c.vol, true, Market{})
+		if !math.IsNaN(g.Delta) || !math.IsNaN(g.Gamma) || !math.IsNaN(g.Vega) || !math.IsNaN(g.Theta) {
+			t.Errorf("%s: %+v, want NaN greeks", c.name, g)
+		}
+	}
+}
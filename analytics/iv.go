@@ -0,0 +1,108 @@
+package analytics
+
+import "math"
+
+// Why an implied volatility could or could not be solved.
+const (
+	StatusOK             = "ok"
+	StatusNoPrice        = "no_price"        // missing or non-positive premium or spot
+	StatusExpired        = "expired"         // at or past the expiry close
+	StatusBelowIntrinsic = "below_intrinsic" // premium at or under intrinsic: no time value
+	StatusAboveBound     = "above_bound"     // premium at or over the no-arbitrage ceiling
+	StatusNoConvergence  = "no_convergence"
+)
+
+// Solver limits. maxVol caps the search; a premium needing more than
+// 1000% volatility is reported as unsolvable rather than extrapolated.
+const (
+	maxVol        = 10.0
+	maxIterations = 100
+	volTolerance  = 1e-10
+)
+
+// ImpliedVol solves the Black-Scholes volatility that reprices premium,
+// t years before expiry. It returns NaN with a status other than
+// StatusOK when no volatility fits.
+//
+// The solve runs on undiscounted forward premiums. In-the-money options
+// are turned into their out-of-the-money twin by put-call parity, whose
+// premium is all time value, so deep ITM strikes stay well conditioned.
+// Newton steps are kept inside a shrinking bracket and fall back to
+// bisection whenever they leave it or vega vanishes.
+func ImpliedVol(premium, spot, strike, t float64, call bool, m Market) (float64, string) {
+	if !(premium > 0) || !(spot > 0) || !(strike > 0) {
+		return math.NaN(), StatusNoPrice
+	}
+	if !(t > 0) {
+		return math.NaN(), StatusExpired
+	}
+
+	forward := m.Forward(spot, t)
+	price := premium / m.Discount(t)
+
+	// upper bound: a call is worth at most the forward, a put the strike
+	if (call && price >= forward) || (!call && price >= strike) {
+		return math.NaN(), StatusAboveBound
+	}
+
+	if call && strike < forward {
+		price -= forward - strike
+		call = false
+	} else if !call && strike > forward {
+		price -= strike - forward
+		call = true
+	}
+
+	if price <= 0 {
+		return math.NaN(), StatusBelowIntrinsic
+	}
+
+	target := func(vol float64) float64 {
+		return Black76(forward, strike, t, vol, 1, call) - price
+	}
+
+	lo, hi := 0.0, maxVol
+	if target(hi) < 0 {
+		return math.NaN(), StatusNoConvergence
+	}
+
+	// Brenner-Subrahmanyam: at-the-money premium is about 0.4 F σ √t
+	vol := math.Sqrt(2*math.Pi/t) * price / forward
+	if !(vol > lo && vol < hi) {
+		vol = 0.5 * (lo + hi)
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		diff := target(vol)
+		if diff == 0 {
+			return vol, StatusOK
+		}
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+
+		if hi-lo < volTolerance {
+			return 0.5 * (lo + hi), StatusOK
+		}
+
+		next := vol - diff/vega(forward, strike, t, vol)
+		if !(next > lo && next < hi) {
+			next = 0.5 * (lo + hi)
+		}
+
+		if math.Abs(next-vol) < volTolerance {
+			return next, StatusOK
+		}
+		vol = next
+	}
+
+	return math.NaN(), StatusNoConvergence
+}
+
+// vega of an undiscounted Black-76 price per unit of volatility.
+func vega(forward, strike, t, vol float64) float64 {
+	d1, _ := d1d2(forward, strike, t, vol)
+	return forward * normPDF(d1) * math.Sqrt(t)
+}
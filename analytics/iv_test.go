@@ -0,0 +1,120 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestImpliedVol(t *testing.T) {
+	hull := Market{Rate: 0.10}
+	carry := Market{Rate: 0.065, DivYield: 0.012}
+
+	tests := []struct {
+		name    string
+		premium float64
+		spot    float64
+		strike  float64
+		t       float64
+		call    bool
+		m       Market
+		want    float64
+	}{
+		// textbook pair (Hull): S 42, K 40, r 10%, six months, 20% vol
+		{"hull call", 4.759422392871536, 42, 40, 0.5, true, hull, 0.2},
+		{"hull put", 0.8085993729000926, 42, 40, 0.5, false, hull, 0.2},
+
+		// repriced from the model: every moneyness and tenor solves back
+		{"atm week", BlackScholes(24000, 24000, 7.0/365, 0.12, true, carry), 24000, 24000, 7.0 / 365, true, carry, 0.12},
+		{"deep itm call", BlackScholes(24000, 18000, 30.0/365, 0.25, true, carry), 24000, 18000, 30.0 / 365, true, carry, 0.25},
+		{"deep otm put", BlackScholes(24000, 18000, 30.0/365, 0.25, false, carry), 24000, 18000, 30.0 / 365, false, carry, 0.25},
+		{"deep itm put", BlackScholes(24000, 30000, 30.0/365, 0.25, false, carry), 24000, 30000, 30.0 / 365, false, carry, 0.25},
+		{"deep otm call", BlackScholes(24000, 30000, 30.0/365, 0.25, true, carry), 24000, 30000, 30.0 / 365, true, carry, 0.25},
+		{"expiry hour", BlackScholes(24000, 24050, 1.0/365/24, 0.15, true, carry), 24000, 24050, 1.0 / 365 / 24, true, carry, 0.15},
+		{"low vol", BlackScholes(24000, 24200, 60.0/365, 0.03, true, carry), 24000, 24200, 60.0 / 365, true, carry, 0.03},
+		{"high vol", BlackScholes(24000, 24000, 0.25, 3, false, carry), 24000, 24000, 0.25, false, carry, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status := ImpliedVol(tt.premium, tt.spot, tt.strike, tt.t, tt.call, tt.m)
+			if status != StatusOK {
+				t.Fatalf("status %s, want %s", status, StatusOK)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("vol %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpliedVolStatus(t *testing.T) {
+	m := Market{Rate: 0.065}
+	tYear := 30.0 / 365
+
+	// forward-terms intrinsic of a 23000 call on 24000 spot, discounted
+	intrinsic := (m.Forward(24000, tYear) - 23000) * m.Discount(tYear)
+
+	tests := []struct {
+		name    string
+		premium float64
+		spot    float64
+		strike  float64
+		t       float64
+		call    bool
+		want    string
+	}{
+		{"zero premium", 0, 24000, 24000, tYear, true, StatusNoPrice},
+		{"nan premium", math.NaN(), 24000, 24000, tYear, true, StatusNoPrice},
+		{"zero spot", 100, 0, 24000, tYear, true, StatusNoPrice},
+		{"zero strike", 100, 24000, 0, tYear, true, StatusNoPrice},
+		{"at expiry", 100, 24000, 24000, 0, true, StatusExpired},
+		{"past expiry", 100, 24000, 24000, -0.001, false, StatusExpired},
+		{"call at intrinsic", intrinsic, 24000, 23000, tYear, true, StatusBelowIntrinsic},
+		{"call under intrinsic", intrinsic - 5, 24000, 23000, tYear, true, StatusBelowIntrinsic},
+		{"put under intrinsic", 850, 24000, 25000, tYear, false, StatusBelowIntrinsic},
+		{"call at the forward", 24000, 24000, 24000, tYear, true, StatusAboveBound},
+		{"put over the strike", 25000, 24000, 24000, tYear, false, StatusAboveBound},
+		// half the forward for a call struck at twice it, an hour out,
+		// needs more than maxVol
+		{"beyond max vol", 12000, 24000, 48000, 1.0 / 365 / 24, true, StatusNoConvergence},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status := ImpliedVol(tt.premium, tt.spot, tt.strike, tt.t, tt.call, m)
+			if status != tt.want {
+				t.Errorf("status %s, want %s", status, tt.want)
+			}
+			if !math.IsNaN(got) {
+				t.Errorf("vol %v, want NaN", got)
+			}
+		})
+	}
+}
+
+func TestBlackScholesBounds(t *testing.T) {
+	m := Market{Rate: 0.065, DivYield: 0.012}
+	spot, tYear := 24000.0, 30.0/365
+	forward, discount := m.Forward(spot, tYear), m.Discount(tYear)
+
+	tests := []struct {
+		name   string
+		strike float64
+		call   bool
+		want   float64 // limit as the strike moves away
+	}{
+		{"deep itm call", 12000, true, (forward - 12000) * discount},
+		{"deep otm call", 48000, true, 0},
+		{"deep itm put", 48000, false, (48000 - forward) * discount},
+		{"deep otm put", 12000, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BlackScholes(spot, tt.strike, tYear, 0.2, tt.call, m)
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("premium %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
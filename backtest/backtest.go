@@ -0,0 +1,212 @@
+// Package backtest replays declarative intraday option strategies over
+// recorded prints. It holds no data access: the components package loads
+// each session's prints and hands them over one day at a time.
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"quant-read-api/models"
+)
+
+// Trigger sources, spot move directions and exit reasons.
+const (
+	OnPremium = "premium" // the leg's own premium, against its side
+	OnSpot    = "spot"    // the spot move since entry, exits every leg
+
+	DirectionUp     = "up"
+	DirectionDown   = "down"
+	DirectionEither = "either"
+
+	ExitStopLoss = "stop_loss"
+	ExitTarget   = "target"
+	ExitTime     = "time"
+)
+
+// Trigger is a stop-loss or target, in points or as a percentage of the
+// entry level. Direction only applies to spot triggers; premium triggers
+// follow the leg's side.
+type Trigger struct {
+	On        string
+	Points    float64
+	Percent   float64
+	Direction string
+}
+
+// threshold is the move from ref that fires the trigger.
+func (t Trigger) threshold(ref float64) float64 {
+	if t.Percent > 0 {
+		return math.Abs(ref) * t.Percent / 100
+	}
+	return t.Points
+}
+
+// spotFired reports whether a spot move from entry reaches the trigger.
+func (t Trigger) spotFired(entry, spot float64) bool {
+	move := spot - entry
+
+	switch t.Direction {
+	case DirectionUp:
+	case DirectionDown:
+		move = -move
+	default:
+		move = math.Abs(move)
+	}
+
+	return move >= t.threshold(entry)
+}
+
+// Rules are the exits a day is run with, besides the time exit.
+type Rules struct {
+	StopLoss *Trigger
+	Target   *Trigger
+}
+
+// Position is one leg as selected at entry.
+type Position struct {
+	Expiry     string
+	Strike     uint32
+	OptionType string
+	Side       string // buy | sell
+	Quantity   int
+}
+
+// sign is +1 for a long position and -1 for a short one.
+func (p Position) sign() float64 {
+	if p.Side == "sell" {
+		return -1
+	}
+	return 1
+}
+
+// pnl is the position's P&L from entry to exit prices.
+func (p Position) pnl(entry, exit float64) float64 {
+	if p.Side == "sell" {
+		return float64(p.Quantity) * (entry - exit)
+	}
+	return float64(p.Quantity) * (exit - entry)
+}
+
+// Tick is one print of one leg, with the spot on it.
+type Tick struct {
+	Ts    time.Time
+	Leg   int
+	Price float64
+	Spot  float64
+}
+
+// legState is a position through the day.
+type legState struct {
+	entered, exited bool
+	last            Tick
+	trade           models.BacktestTrade
+}
+
+// RunDay replays one session. ticks hold every leg's prints from entry to
+// the time exit, in time order. A leg fills at its first print; premium
+// triggers close it on its own prints, spot triggers close every open leg
+// at its latest print and end the day. Legs still open after the last
+// print exit on time. Legs that never printed produce no trade.
+func RunDay(date string, positions []Position, ticks []Tick, rules Rules) []models.BacktestTrade {
+	legs := make([]legState, len(positions))
+
+	exit := func(l *legState, t Tick, reason string) {
+		p := positions[l.trade.Leg-1]
+
+		l.exited = true
+		l.trade.ExitTs = l.last.Ts
+		l.trade.ExitPrice = l.last.Price
+		l.trade.ExitSpot = t.Spot
+		l.trade.ExitReason = reason
+		l.trade.Pnl = p.pnl(l.trade.EntryPrice, l.last.Price)
+	}
+
+	var spotEntry float64
+	spotSet := false
+
+	triggers := []struct {
+		t      *Trigger
+		reason string
+	}{
+		{rules.StopLoss, ExitStopLoss},
+		{rules.Target, ExitTarget},
+	}
+
+replay:
+	for _, t := range ticks {
+		if !spotSet {
+			spotEntry, spotSet = t.Spot, true
+		}
+
+		l := &legs[t.Leg]
+		if l.exited {
+			continue
+		}
+
+		p := positions[t.Leg]
+		l.last = t
+
+		if !l.entered {
+			l.entered = true
+			l.trade = models.BacktestTrade{
+				Date:       date,
+				Leg:        t.Leg + 1,
+				Expiry:     p.Expiry,
+				Strike:     p.Strike,
+				OptionType: p.OptionType,
+				Side:       p.Side,
+				Quantity:   p.Quantity,
+				EntryTs:    t.Ts,
+				EntryPrice: t.Price,
+				EntrySpot:  t.Spot,
+			}
+		}
+
+		// premium triggers, stop first
+		gain := p.sign() * (t.Price - l.trade.EntryPrice)
+		for _, pt := range triggers {
+			if pt.t == nil || pt.t.On != OnPremium {
+				continue
+			}
+
+			move := gain
+			if pt.reason == ExitStopLoss {
+				move = -gain
+			}
+			if move >= pt.t.threshold(l.trade.EntryPrice) {
+				exit(l, t, pt.reason)
+				break
+			}
+		}
+
+		// spot triggers, stop first
+		for _, pt := range triggers {
+			if pt.t == nil || pt.t.On != OnSpot || !pt.t.spotFired(spotEntry, t.Spot) {
+				continue
+			}
+
+			for i := range legs {
+				if legs[i].entered && !legs[i].exited {
+					exit(&legs[i], t, pt.reason)
+				}
+			}
+			break replay
+		}
+	}
+
+	out := []models.BacktestTrade{}
+
+	for i := range legs {
+		l := &legs[i]
+		if !l.entered {
+			continue
+		}
+		if !l.exited {
+			exit(l, l.last, ExitTime)
+		}
+		out = append(out, l.trade)
+	}
+
+	return out
+}
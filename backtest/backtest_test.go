@@ -0,0 +1,276 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"quant-read-api/models"
+)
+
+var t0 = time.Date(2025, 11, 3, 9, 20, 0, 0, time.UTC)
+
+// tick is one print: leg, price and spot, a second after the previous one.
+type tick struct {
+	leg         int
+	price, spot float64
+}
+
+func ticksOf(in []tick) []Tick {
+	out := make([]Tick, len(in))
+	for i, t := range in {
+		out[i] = Tick{Ts: t0.Add(time.Duration(i) * time.Second), Leg: t.leg, Price: t.price, Spot: t.spot}
+	}
+	return out
+}
+
+// exit is the expected outcome of one trade.
+type exit struct {
+	leg      int // 1-based, as reported
+	entry    float64
+	price    float64
+	at       int // tick index of the exit print
+	exitSpot float64
+	reason   string
+	pnl      float64
+}
+
+func TestRunDay(t *testing.T) {
+	sell := Position{Expiry: "2025-11-04", Strike: 25750, OptionType: "CE", Side: "sell", Quantity: 75}
+	buy := Position{Expiry: "2025-11-04", Strike: 25750, OptionType: "PE", Side: "buy", Quantity: 75}
+
+	tests := []struct {
+		name      string
+		positions []Position
+		ticks     []tick
+		rules     Rules
+		want      []exit
+	}{
+		{
+			name:      "time exit at the last print",
+			positions: []Position{sell},
+			ticks:     []tick{{0, 100, 25750}, {0, 104, 25760}, {0, 92, 25740}},
+			want:      []exit{{1, 100, 92, 2, 25740, ExitTime, 600}},
+		},
+		{
+			name:      "short leg stops out when its premium rises",
+			positions: []Position{sell},
+			ticks:     []tick{{0, 100, 25750}, {0, 118, 25780}, {0, 131, 25800}, {0, 90, 25700}},
+			rules:     Rules{StopLoss: &Trigger{On: OnPremium, Percent: 30}},
+			want:      []exit{{1, 100, 131, 2, 25800, ExitStopLoss, -2325}},
+		},
+		{
+			name:      "long leg takes its target in points",
+			positions: []Position{buy},
+			ticks:     []tick{{0, 80, 25750}, {0, 95, 25700}, {0, 70, 25790}},
+			rules:     Rules{Target: &Trigger{On: OnPremium, Points: 15}},
+			want:      []exit{{1, 80, 95, 1, 25700, ExitTarget, 1125}},
+		},
+		{
+			name:      "premium exit closes its own leg only",
+			positions: []Position{sell, buy},
+			ticks:     []tick{{0, 100, 25750}, {1, 80, 25750}, {0, 60, 25700}, {1, 110, 25700}, {1, 105, 25710}},
+			rules:     Rules{Target: &Trigger{On: OnPremium, Percent: 40}},
+			want: []exit{
+				{1, 100, 60, 2, 25700, ExitTarget, 3000},
+				{2, 80, 105, 4, 25710, ExitTime, 1875},
+			},
+		},
+		{
+			// one print clears both the spot stop and the spot target
+			name:      "stop wins over target on the same print",
+			positions: []Position{sell},
+			ticks:     []tick{{0, 100, 25750}, {0, 140, 25830}},
+			rules: Rules{
+				StopLoss: &Trigger{On: OnSpot, Points: 50},
+				Target:   &Trigger{On: OnSpot, Points: 30},
+			},
+			want: []exit{{1, 100, 140, 1, 25830, ExitStopLoss, -3000}},
+		},
+		{
+			name:      "spot trigger closes every leg at its latest print and ends the day",
+			positions: []Position{sell, buy},
+			ticks:     []tick{{0, 100, 25750}, {1, 80, 25750}, {0, 90, 25720}, {1, 96, 25690}, {0, 50, 25600}},
+			rules:     Rules{Target: &Trigger{On: OnSpot, Points: 50, Direction: DirectionDown}},
+			want: []exit{
+				{1, 100, 90, 2, 25690, ExitTarget, 750},
+				{2, 80, 96, 3, 25690, ExitTarget, 1200},
+			},
+		},
+		{
+			name:      "spot direction ignores the other way",
+			positions: []Position{sell},
+			ticks:     []tick{{0, 100, 25750}, {0, 120, 25850}, {0, 95, 25740}},
+			rules:     Rules{StopLoss: &Trigger{On: OnSpot, Points: 50, Direction: DirectionDown}},
+			want:      []exit{{1, 100, 95, 2, 25740, ExitTime, 375}},
+		},
+		{
+			name:      "leg that never printed has no trade",
+			positions: []Position{sell, buy},
+			ticks:     []tick{{1, 80, 25750}, {1, 82, 25755}},
+			want:      []exit{{2, 80, 82, 1, 25755, ExitTime, 150}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticks := ticksOf(tt.ticks)
+			got := RunDay("2025-11-03", tt.positions, ticks, tt.rules)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d trades, want %d: %+v", len(got), len(tt.want), got)
+			}
+
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Leg != w.leg || g.EntryPrice != w.entry || g.ExitPrice != w.price ||
+					g.ExitReason != w.reason || g.ExitSpot != w.exitSpot {
+					t.Errorf("trade %d: leg %d %v -> %v spot %v (%s), want leg %d %v -> %v spot %v (%s)",
+						i, g.Leg, g.EntryPrice, g.ExitPrice, g.ExitSpot, g.ExitReason,
+						w.leg, w.entry, w.price, w.exitSpot, w.reason)
+				}
+				if !g.ExitTs.Equal(ticks[w.at].Ts) {
+					t.Errorf("trade %d: exit at %v, want %v", i, g.ExitTs, ticks[w.at].Ts)
+				}
+				if math.Abs(g.Pnl-w.pnl) > 1e-9 {
+					t.Errorf("trade %d: pnl %v, want %v", i, g.Pnl, w.pnl)
+				}
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	trade := func(date string, pnl float64) models.BacktestTrade {
+		return models.BacktestTrade{Date: date, Pnl: pnl}
+	}
+
+	trades := []models.BacktestTrade{
+		trade("2025-11-03", 500),
+		trade("2025-11-03", -200), // day +300
+		trade("2025-11-04", -400), // day -400
+		trade("2025-11-05", 100),
+		trade("2025-11-05", 100),  // day +200
+		trade("2025-11-06", -500), // day -500
+		trade("2025-11-07", 1000), // day +1000
+	}
+
+	daily, s := Summarize(trades)
+
+	wantDaily := []struct {
+		date                      string
+		trades                    int
+		pnl, cumulative, drawdown float64
+	}{
+		{"2025-11-03", 2, 300, 300, 0},
+		{"2025-11-04", 1, -400, -100, 400},
+		{"2025-11-05", 2, 200, 100, 200},
+		{"2025-11-06", 1, -500, -400, 700},
+		{"2025-11-07", 1, 1000, 600, 0},
+	}
+
+	if len(daily.Date) != len(wantDaily) {
+		t.Fatalf("got %d days, want %d", len(daily.Date), len(wantDaily))
+	}
+	for i, w := range wantDaily {
+		if daily.Date[i] != w.date || daily.Trades[i] != w.trades || daily.Pnl[i] != w.pnl ||
+			daily.Cumulative[i] != w.cumulative || daily.Drawdown[i] != w.drawdown {
+			t.Errorf("day %d: %s %d %v %v %v, want %+v", i,
+				daily.Date[i], daily.Trades[i], daily.Pnl[i], daily.Cumulative[i], daily.Drawdown[i], w)
+		}
+	}
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"days", float64(s.Days), 5},
+		{"trades", float64(s.Trades), 7},
+		{"net pnl", s.NetPnl, 600},
+		{"win days", float64(s.WinDays), 3},
+		{"loss days", float64(s.LossDays), 2},
+		{"win rate", s.WinRate, 60},
+		{"best day", s.BestDay, 1000},
+		{"worst day", s.WorstDay, -500},
+		{"avg win day", deref(s.AvgWinDay), 500},
+		{"avg loss day", deref(s.AvgLossDay), -450},
+		{"profit factor", deref(s.ProfitFactor), 1500.0 / 900},
+		{"max drawdown", s.MaxDrawdown, 700},
+		{"trade win rate", s.TradeWinRate, 100 * 4.0 / 7},
+		{"expectancy", deref(s.Expectancy), 600.0 / 7},
+		// daily mean 120, sample variance 367000
+		{"sharpe", deref(s.Sharpe), 120 * math.Sqrt(252) / math.Sqrt(367000)},
+	}
+
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 1e-9 {
+			t.Errorf("%s %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestSummarizeEdges(t *testing.T) {
+	tests := []struct {
+		name   string
+		trades []models.BacktestTrade
+		check  func(t *testing.T, s models.BacktestSummary)
+	}{
+		{
+			name:   "no trades",
+			trades: nil,
+			check: func(t *testing.T, s models.BacktestSummary) {
+				if s.Days != 0 || s.WinRate != 0 || s.Expectancy != nil || s.Sharpe != nil {
+					t.Errorf("got %+v, want an empty summary", s)
+				}
+			},
+		},
+		{
+			name:   "no losing day has no profit factor",
+			trades: []models.BacktestTrade{{Date: "2025-11-03", Pnl: 100}, {Date: "2025-11-04", Pnl: 300}},
+			check: func(t *testing.T, s models.BacktestSummary) {
+				if s.ProfitFactor != nil || s.AvgLossDay != nil {
+					t.Errorf("profit factor %v, avg loss %v, want none", s.ProfitFactor, s.AvgLossDay)
+				}
+				if s.MaxDrawdown != 0 || s.WinRate != 100 {
+					t.Errorf("drawdown %v, win rate %v, want 0 and 100", s.MaxDrawdown, s.WinRate)
+				}
+			},
+		},
+		{
+			name:   "a single day has no sharpe",
+			trades: []models.BacktestTrade{{Date: "2025-11-03", Pnl: -100}},
+			check: func(t *testing.T, s models.BacktestSummary) {
+				if s.Sharpe != nil {
+					t.Errorf("sharpe %v, want none", *s.Sharpe)
+				}
+				// the first day's loss counts from a zero peak
+				if s.MaxDrawdown != 100 || s.BestDay != -100 || s.WorstDay != -100 {
+					t.Errorf("drawdown %v, best %v, worst %v", s.MaxDrawdown, s.BestDay, s.WorstDay)
+				}
+			},
+		},
+		{
+			name:   "flat days count as neither win nor loss",
+			trades: []models.BacktestTrade{{Date: "2025-11-03", Pnl: 0}, {Date: "2025-11-04", Pnl: 50}},
+			check: func(t *testing.T, s models.BacktestSummary) {
+				if s.WinDays != 1 || s.LossDays != 0 || s.WinRate != 50 {
+					t.Errorf("win %d, loss %d, rate %v", s.WinDays, s.LossDays, s.WinRate)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, s := Summarize(tt.trades)
+			tt.check(t, s)
+		})
+	}
+}
+
+func deref(v *float64) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return *v
+}
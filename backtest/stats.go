@@ -0,0 +1,112 @@
+package backtest
+
+import (
+	"math"
+
+	"quant-read-api/models"
+)
+
+// tradingDays annualises the Sharpe ratio of daily P&L.
+const tradingDays = 252
+
+// Summarize folds a run's trades, ordered by date, into daily P&L and
+// summary statistics.
+func Summarize(trades []models.BacktestTrade) (models.BacktestDaily, models.BacktestSummary) {
+	daily := models.BacktestDaily{
+		Date:       []string{},
+		Trades:     []int{},
+		Pnl:        models.FloatColumn{},
+		Cumulative: models.FloatColumn{},
+		Drawdown:   models.FloatColumn{},
+	}
+
+	for _, t := range trades {
+		n := len(daily.Date)
+		if n == 0 || daily.Date[n-1] != t.Date {
+			daily.Date = append(daily.Date, t.Date)
+			daily.Trades = append(daily.Trades, 0)
+			daily.Pnl = append(daily.Pnl, 0)
+			n++
+		}
+		daily.Trades[n-1]++
+		daily.Pnl[n-1] += t.Pnl
+	}
+
+	s := models.BacktestSummary{
+		Days:   len(daily.Date),
+		Trades: len(trades),
+	}
+
+	var cumulative, peak, grossWin, grossLoss, sumSq float64
+
+	for i, pnl := range daily.Pnl {
+		cumulative += pnl
+		peak = max(peak, cumulative)
+
+		daily.Cumulative = append(daily.Cumulative, cumulative)
+		daily.Drawdown = append(daily.Drawdown, peak-cumulative)
+
+		s.MaxDrawdown = max(s.MaxDrawdown, peak-cumulative)
+
+		if i == 0 || pnl > s.BestDay {
+			s.BestDay = pnl
+		}
+		if i == 0 || pnl < s.WorstDay {
+			s.WorstDay = pnl
+		}
+
+		switch {
+		case pnl > 0:
+			s.WinDays++
+			grossWin += pnl
+		case pnl < 0:
+			s.LossDays++
+			grossLoss -= pnl
+		}
+
+		sumSq += pnl * pnl
+	}
+
+	s.NetPnl = cumulative
+
+	if s.Days == 0 {
+		return daily, s
+	}
+
+	days := float64(s.Days)
+	s.WinRate = 100 * float64(s.WinDays) / days
+
+	if s.WinDays > 0 {
+		s.AvgWinDay = ratio(grossWin, float64(s.WinDays))
+	}
+	if s.LossDays > 0 {
+		s.AvgLossDay = ratio(-grossLoss, float64(s.LossDays))
+		s.ProfitFactor = ratio(grossWin, grossLoss)
+	}
+
+	if s.Days > 1 {
+		mean := cumulative / days
+		variance := (sumSq - days*mean*mean) / (days - 1)
+		if variance > 0 {
+			s.Sharpe = ratio(mean*math.Sqrt(tradingDays), math.Sqrt(variance))
+		}
+	}
+
+	wins := 0
+	for _, t := range trades {
+		if t.Pnl > 0 {
+			wins++
+		}
+	}
+	if len(trades) > 0 {
+		s.TradeWinRate = 100 * float64(wins) / float64(len(trades))
+		s.Expectancy = ratio(cumulative, float64(len(trades)))
+	}
+
+	return daily, s
+}
+
+func ratio(a, b float64) *float64 {
+	v := a / b
+	return &v
+}
@@ -0,0 +1,112 @@
+package bars
+
+import (
+	"time"
+
+	"quant-read-api/models"
+)
+
+// Tick is one second-level price, tagged with the trading session it
+// belongs to so bars can be cut at session boundaries.
+type Tick struct {
+	Ts      time.Time
+	Price   float64
+	Session int
+}
+
+// builder accumulates ticks into one bar at a time.
+type builder struct {
+	out models.ColumnarOHLC
+
+	open, high, low, close float64
+	first, last            time.Time
+	count                  uint64
+	active                 bool
+}
+
+func newBuilder() *builder {
+	return &builder{
+		out: models.ColumnarOHLC{
+			Ts:     []time.Time{},
+			Open:   []float64{},
+			High:   []float64{},
+			Low:    []float64{},
+			Close:  []float64{},
+			Count:  []uint64{},
+			LastTs: []time.Time{},
+		},
+	}
+}
+
+func (b *builder) add(t Tick) {
+	if !b.active {
+		b.open, b.high, b.low = t.Price, t.Price, t.Price
+		b.first = t.Ts
+		b.count = 0
+		b.active = true
+	}
+
+	b.high = max(b.high, t.Price)
+	b.low = min(b.low, t.Price)
+	b.close = t.Price
+	b.last = t.Ts
+	b.count++
+}
+
+func (b *builder) flush() {
+	if !b.active {
+		return
+	}
+
+	b.out.Ts = append(b.out.Ts, b.first)
+	b.out.Open = append(b.out.Open, b.open)
+	b.out.High = append(b.out.High, b.high)
+	b.out.Low = append(b.out.Low, b.low)
+	b.out.Close = append(b.out.Close, b.close)
+	b.out.Count = append(b.out.Count, b.count)
+	b.out.LastTs = append(b.out.LastTs, b.last)
+
+	b.active = false
+}
+
+// TickCount builds bars of n ticks each. A session's last bar may hold
+// fewer ticks; bars never span two sessions.
+func TickCount(ticks []Tick, n int) models.ColumnarOHLC {
+	b := newBuilder()
+
+	for i, t := range ticks {
+		if i > 0 && t.Session != ticks[i-1].Session {
+			b.flush()
+		}
+
+		b.add(t)
+
+		if b.count == uint64(n) {
+			b.flush()
+		}
+	}
+	b.flush()
+
+	return b.out
+}
+
+// Range builds bars whose high-low span never exceeds size. The tick that
+// would break the range opens the next bar. Bars never span two sessions.
+func Range(ticks []Tick, size float64) models.ColumnarOHLC {
+	b := newBuilder()
+
+	for i, t := range ticks {
+		if i > 0 && t.Session != ticks[i-1].Session {
+			b.flush()
+		}
+
+		if b.active && max(b.high, t.Price)-min(b.low, t.Price) > size {
+			b.flush()
+		}
+
+		b.add(t)
+	}
+	b.flush()
+
+	return b.out
+}
@@ -0,0 +1,252 @@
+package bars
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"quant-read-api/models"
+)
+
+var t0 = time.Date(2025, 11, 3, 9, 15, 0, 0, time.UTC)
+
+// ticksOf builds one tick per second; sessions[i] tags the i-th price,
+// session 1 when nil.
+func ticksOf(prices []float64, sessions []int) []Tick {
+	out := make([]Tick, len(prices))
+	for i, p := range prices {
+		s := 1
+		if sessions != nil {
+			s = sessions[i]
+		}
+		out[i] = Tick{Ts: t0.Add(time.Duration(i) * time.Second), Price: p, Session: s}
+	}
+	return out
+}
+
+func at(i int) time.Time {
+	return t0.Add(time.Duration(i) * time.Second)
+}
+
+// bar is the expected shape of one output row.
+type bar struct {
+	open, high, low, close float64
+	count                  uint64
+	ts, lastTs             int // tick indices
+}
+
+func checkBars(t *testing.T, got models.ColumnarOHLC, want []bar) {
+	t.Helper()
+
+	if len(got.Ts) != len(want) {
+		t.Fatalf("got %d bars, want %d: %+v", len(got.Ts), len(want), got)
+	}
+
+	for i, w := range want {
+		if got.Open[i] != w.open || got.High[i] != w.high || got.Low[i] != w.low || got.Close[i] != w.close {
+			t.Errorf("bar %d: OHLC %v %v %v %v, want %v %v %v %v",
+				i, got.Open[i], got.High[i], got.Low[i], got.Close[i], w.open, w.high, w.low, w.close)
+		}
+		if got.Count[i] != w.count {
+			t.Errorf("bar %d: count %d, want %d", i, got.Count[i], w.count)
+		}
+		if !got.Ts[i].Equal(at(w.ts)) || !got.LastTs[i].Equal(at(w.lastTs)) {
+			t.Errorf("bar %d: ts %v..%v, want %v..%v", i, got.Ts[i], got.LastTs[i], at(w.ts), at(w.lastTs))
+		}
+	}
+}
+
+func TestRenko(t *testing.T) {
+	tests := []struct {
+		name    string
+		prices  []float64
+		size    float64
+		percent bool
+		want    []bar
+	}{
+		{
+			name:   "no move",
+			prices: []float64{100, 105, 96},
+			size:   10,
+			want:   []bar{},
+		},
+		{
+			name:   "one brick up",
+			prices: []float64{100, 105, 112},
+			size:   10,
+			want:   []bar{{100, 110, 100, 110, 3, 0, 2}},
+		},
+		{
+			name:   "one tick, two bricks",
+			prices: []float64{100, 112, 135},
+			size:   10,
+			want: []bar{
+				{100, 110, 100, 110, 2, 0, 1},
+				{110, 120, 110, 120, 1, 2, 2},
+				{120, 130, 120, 130, 0, 2, 2},
+			},
+		},
+		{
+			name:   "reversal needs two bricks",
+			prices: []float64{100, 112, 101, 99},
+			size:   10,
+			want:   []bar{{100, 110, 100, 110, 2, 0, 1}},
+		},
+		{
+			name:   "reversal from the far side",
+			prices: []float64{100, 112, 101, 89},
+			size:   10,
+			want: []bar{
+				{100, 110, 100, 110, 2, 0, 1},
+				{100, 100, 90, 90, 2, 2, 3},
+			},
+		},
+		{
+			name:    "percent of the level",
+			prices:  []float64{200, 201, 202, 204},
+			size:    1,
+			percent: true,
+			// the next brick is 1% of 202
+			want: []bar{{200, 202, 200, 202, 3, 0, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkBars(t, Renko(ticksOf(tt.prices, nil), tt.size, tt.percent), tt.want)
+		})
+	}
+}
+
+func TestRenkoCrossesSessions(t *testing.T) {
+	got := Renko(ticksOf([]float64{100, 105, 110}, []int{1, 1, 2}), 10, false)
+	checkBars(t, got, []bar{{100, 110, 100, 110, 3, 0, 2}})
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64
+		sessions []int
+		size     float64
+		want     []bar
+	}{
+		{
+			name:   "span equal to size stays in the bar",
+			prices: []float64{100, 103, 98},
+			size:   5,
+			want:   []bar{{100, 103, 98, 98, 3, 0, 2}},
+		},
+		{
+			name:   "breaking tick opens the next bar",
+			prices: []float64{100, 103, 98, 106, 104},
+			size:   5,
+			want: []bar{
+				{100, 103, 98, 98, 3, 0, 2},
+				{106, 106, 104, 104, 2, 3, 4},
+			},
+		},
+		{
+			name:     "session boundary closes the bar",
+			prices:   []float64{100, 101, 101},
+			sessions: []int{1, 1, 2},
+			size:     5,
+			want: []bar{
+				{100, 101, 100, 101, 2, 0, 1},
+				{101, 101, 101, 101, 1, 2, 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkBars(t, Range(ticksOf(tt.prices, tt.sessions), tt.size), tt.want)
+		})
+	}
+}
+
+func TestTickCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64
+		sessions []int
+		n        int
+		want     []bar
+	}{
+		{
+			name:   "full bars and a short last one",
+			prices: []float64{1, 3, 2, 4, 5},
+			n:      2,
+			want: []bar{
+				{1, 3, 1, 3, 2, 0, 1},
+				{2, 4, 2, 4, 2, 2, 3},
+				{5, 5, 5, 5, 1, 4, 4},
+			},
+		},
+		{
+			name:     "session boundary closes the bar",
+			prices:   []float64{1, 2, 3, 4},
+			sessions: []int{1, 1, 1, 2},
+			n:        2,
+			want: []bar{
+				{1, 2, 1, 2, 2, 0, 1},
+				{3, 3, 3, 3, 1, 2, 2},
+				{4, 4, 4, 4, 1, 3, 3},
+			},
+		},
+		{
+			name:   "no ticks",
+			prices: []float64{},
+			n:      3,
+			want:   []bar{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkBars(t, TickCount(ticksOf(tt.prices, tt.sessions), tt.n), tt.want)
+		})
+	}
+}
+
+func TestHeikinAshi(t *testing.T) {
+	nan := math.NaN()
+
+	in := models.ColumnarOHLC{
+		Ts:     []time.Time{at(0), at(60), at(120)},
+		Open:   []float64{10, nan, 11},
+		High:   []float64{12, nan, 13},
+		Low:    []float64{9, nan, 10},
+		Close:  []float64{11, nan, 12},
+		Filled: []bool{false, true, false},
+	}
+
+	got := HeikinAshi(in)
+
+	want := [][4]float64{
+		{10.5, 12, 9, 10.5},  // first candle opens at (o + c) / 2
+		{nan, nan, nan, nan}, // null candles stay null
+		{10.5, 13, 10, 11.5}, // opens at the previous HA midpoint, skipping the null
+	}
+
+	same := func(a, b float64) bool {
+		return a == b || (math.IsNaN(a) && math.IsNaN(b))
+	}
+
+	for i, w := range want {
+		g := [4]float64{got.Open[i], got.High[i], got.Low[i], got.Close[i]}
+		for k := range w {
+			if !same(g[k], w[k]) {
+				t.Errorf("candle %d: got %v, want %v", i, g, w)
+				break
+			}
+		}
+	}
+
+	if len(got.Filled) != 3 || !got.Filled[1] {
+		t.Errorf("filled not carried through: %v", got.Filled)
+	}
+	if in.Open[0] != 10 {
+		t.Errorf("input modified: open %v", in.Open[0])
+	}
+}
@@ -0,0 +1,45 @@
+package bars
+
+import (
+	"math"
+
+	"quant-read-api/models"
+)
+
+// HeikinAshi smooths time candles in place of their OHLC. Other columns
+// (aggregations, filled, partial) are carried through untouched. Null
+// candles stay null and do not advance the smoothing.
+func HeikinAshi(c models.ColumnarOHLC) models.ColumnarOHLC {
+	out := c
+	out.Open = make([]float64, len(c.Ts))
+	out.High = make([]float64, len(c.Ts))
+	out.Low = make([]float64, len(c.Ts))
+	out.Close = make([]float64, len(c.Ts))
+
+	prevOpen, prevClose := math.NaN(), math.NaN()
+
+	for i := range c.Ts {
+		o, h, l, cl := c.Open[i], c.High[i], c.Low[i], c.Close[i]
+
+		if math.IsNaN(cl) {
+			out.Open[i], out.High[i], out.Low[i], out.Close[i] = o, h, l, cl
+			continue
+		}
+
+		haClose := (o + h + l + cl) / 4
+
+		haOpen := (o + cl) / 2
+		if !math.IsNaN(prevOpen) {
+			haOpen = (prevOpen + prevClose) / 2
+		}
+
+		out.Open[i] = haOpen
+		out.High[i] = max(h, haOpen, haClose)
+		out.Low[i] = min(l, haOpen, haClose)
+		out.Close[i] = haClose
+
+		prevOpen, prevClose = haOpen, haClose
+	}
+
+	return out
+}
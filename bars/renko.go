@@ -0,0 +1,103 @@
+package bars
+
+import (
+	"time"
+
+	"quant-read-api/models"
+)
+
+// Renko builds bricks of a fixed size, in price points or, when percent
+// is set, as a percentage of the level the brick starts from. A new brick
+// in the current direction needs a one-brick move; a reversal needs two.
+// Bricks run across sessions, as the chart type is price-only.
+//
+// Ts is the first tick after the previous brick, LastTs the tick that
+// completed this one, and Count the ticks in between. A single large move
+// can complete several bricks on one tick: the first of them carries all
+// the ticks, the rest have Count 0 and Ts = LastTs = that tick.
+func Renko(ticks []Tick, size float64, percent bool) models.ColumnarOHLC {
+	out := models.ColumnarOHLC{
+		Ts:     []time.Time{},
+		Open:   []float64{},
+		High:   []float64{},
+		Low:    []float64{},
+		Close:  []float64{},
+		Count:  []uint64{},
+		LastTs: []time.Time{},
+	}
+
+	if len(ticks) == 0 {
+		return out
+	}
+
+	brick := func(level float64) float64 {
+		if percent {
+			return level * size / 100
+		}
+		return size
+	}
+
+	level := ticks[0].Price // close of the last brick
+	direction := 0          // +1 up, -1 down, 0 before the first brick
+
+	var first time.Time
+	var count uint64
+
+	emit := func(open, close float64, last time.Time) {
+		out.Ts = append(out.Ts, first)
+		out.Open = append(out.Open, open)
+		out.High = append(out.High, max(open, close))
+		out.Low = append(out.Low, min(open, close))
+		out.Close = append(out.Close, close)
+		out.Count = append(out.Count, count)
+		out.LastTs = append(out.LastTs, last)
+		count = 0
+	}
+
+	// next forms one brick if price has moved far enough from level
+	next := func(price float64) (open, close float64, ok bool) {
+		step := brick(level)
+		if step <= 0 {
+			return 0, 0, false
+		}
+
+		switch {
+		case direction >= 0 && price >= level+step:
+			open, close, direction = level, level+step, 1
+
+		case direction <= 0 && price <= level-step:
+			open, close, direction = level, level-step, -1
+
+		// reversals start from the far side of the last brick
+		case direction > 0 && price <= level-2*step:
+			open, close, direction = level-step, level-2*step, -1
+
+		case direction < 0 && price >= level+2*step:
+			open, close, direction = level+step, level+2*step, 1
+
+		default:
+			return 0, 0, false
+		}
+
+		level = close
+		return open, close, true
+	}
+
+	for _, t := range ticks {
+		if count == 0 {
+			first = t.Ts
+		}
+		count++
+
+		for {
+			open, close, ok := next(t.Price)
+			if !ok {
+				break
+			}
+			emit(open, close, t.Ts)
+			first = t.Ts
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,278 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	"quant-read-api/models"
+)
+
+// Session is one trading session of the exchange, in exchange local time.
+type Session struct {
+	Date  time.Time // midnight of the trading day
+	Open  time.Time
+	Close time.Time
+	Kind  string // regular | special | early_close
+	Name  string
+}
+
+// Clamp intersects the session with [from, to). ok is false when the
+// two windows do not overlap.
+func (s Session) Clamp(from, to time.Time) (start, end time.Time, ok bool) {
+	start = s.Open
+	if from.After(start) {
+		start = from
+	}
+
+	end = s.Close
+	if to.Before(end) {
+		end = to
+	}
+
+	return start, end, start.Before(end)
+}
+
+type exchangeFile struct {
+	Timezone       string       `json:"timezone"`
+	RegularSession sessionHours `json:"regular_session"`
+	Weekend        []string     `json:"weekend"`
+
+	// Coverage is the date range the holidays and special sessions are
+	// maintained for; Sessions refuses days outside it.
+	Coverage dateRange `json:"coverage"`
+
+	Holidays        []dayEntry `json:"holidays"`
+	SpecialSessions []dayEntry `json:"special_sessions"`
+	EarlyCloses     []dayEntry `json:"early_closes"`
+}
+
+type sessionHours struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+type dateRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type dayEntry struct {
+	Date  string `json:"date"`
+	Name  string `json:"name"`
+	Open  string `json:"open,omitempty"`
+	Close string `json:"close,omitempty"`
+}
+
+// Calendar holds the sessions of one exchange.
+type Calendar struct {
+	Exchange string
+
+	loc     *time.Location
+	open    clock
+	close   clock
+	weekend map[time.Weekday]bool
+
+	coverFrom, coverTo string // YYYY-MM-DD, inclusive
+
+	holidays map[string]dayEntry
+	special  map[string]dayEntry
+	early    map[string]dayEntry
+}
+
+type clock struct {
+	hour, min int
+}
+
+func parse(code string, f exchangeFile) (*Calendar, error) {
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	openAt, err := parseClock(f.RegularSession.Open)
+	if err != nil {
+		return nil, err
+	}
+	closeAt, err := parseClock(f.RegularSession.Close)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Calendar{
+		Exchange: code,
+		loc:      loc,
+		open:     openAt,
+		close:    closeAt,
+		weekend:  map[time.Weekday]bool{},
+		holidays: map[string]dayEntry{},
+		special:  map[string]dayEntry{},
+		early:    map[string]dayEntry{},
+	}
+
+	if f.Coverage.From == "" || f.Coverage.To == "" {
+		return nil, fmt.Errorf("coverage needs from and to")
+	}
+	for _, d := range []string{f.Coverage.From, f.Coverage.To} {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return nil, fmt.Errorf("coverage %q: %w", d, err)
+		}
+	}
+	c.coverFrom, c.coverTo = f.Coverage.From, f.Coverage.To
+
+	for _, name := range f.Weekend {
+		wd, err := parseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		c.weekend[wd] = true
+	}
+
+	for _, e := range f.Holidays {
+		if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+			return nil, fmt.Errorf("holiday %q: %w", e.Date, err)
+		}
+		c.holidays[e.Date] = e
+	}
+
+	for _, e := range f.SpecialSessions {
+		if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+			return nil, fmt.Errorf("special session %q: %w", e.Date, err)
+		}
+		if _, err := parseClock(e.Open); err != nil {
+			return nil, fmt.Errorf("special session %s: %w", e.Date, err)
+		}
+		if _, err := parseClock(e.Close); err != nil {
+			return nil, fmt.Errorf("special session %s: %w", e.Date, err)
+		}
+		c.special[e.Date] = e
+	}
+
+	for _, e := range f.EarlyCloses {
+		if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+			return nil, fmt.Errorf("early close %q: %w", e.Date, err)
+		}
+		if _, err := parseClock(e.Close); err != nil {
+			return nil, fmt.Errorf("early close %s: %w", e.Date, err)
+		}
+		c.early[e.Date] = e
+	}
+
+	return c, nil
+}
+
+func parseClock(s string) (clock, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return clock{}, fmt.Errorf("invalid clock time %q", s)
+	}
+	return clock{hour: t.Hour(), min: t.Minute()}, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if d.String() == s {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+func (c clock) on(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.hour, c.min, 0, 0, day.Location())
+}
+
+// Day returns the session held on the given day. When the exchange is
+// closed, ok is false and reason says why.
+func (c *Calendar) Day(day time.Time) (s Session, ok bool, reason string) {
+	day = day.In(c.loc)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, c.loc)
+	key := day.Format("2006-01-02")
+
+	// special sessions win over weekends and holidays (Muhurat, budget day)
+	if e, found := c.special[key]; found {
+		openAt, _ := parseClock(e.Open)
+		closeAt, _ := parseClock(e.Close)
+		return Session{
+			Date:  day,
+			Open:  openAt.on(day),
+			Close: closeAt.on(day),
+			Kind:  "special",
+			Name:  e.Name,
+		}, true, ""
+	}
+
+	if e, found := c.holidays[key]; found {
+		return Session{}, false, "holiday: " + e.Name
+	}
+
+	if c.weekend[day.Weekday()] {
+		return Session{}, false, "weekend"
+	}
+
+	s = Session{
+		Date:  day,
+		Open:  c.open.on(day),
+		Close: c.close.on(day),
+		Kind:  "regular",
+	}
+
+	if e, found := c.early[key]; found {
+		closeAt, _ := parseClock(e.Close)
+		s.Close = closeAt.on(day)
+		s.Kind = "early_close"
+		s.Name = e.Name
+	}
+
+	return s, true, ""
+}
+
+// Sessions walks every calendar day touched by [from, to) and returns the
+// trading sessions overlapping that window, along with the days that were
+// skipped because the exchange was closed. Days outside the configured
+// coverage fall back to the regular weekday session: their holidays are
+// unknown, so their data is served rather than dropped.
+func (c *Calendar) Sessions(from, to time.Time) ([]Session, []models.SkippedSession) {
+	sessions := []Session{}
+	skipped := []models.SkippedSession{}
+
+	from = from.In(c.loc)
+	to = to.In(c.loc)
+
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, c.loc)
+	lastDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, c.loc)
+
+	for ; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		s, ok, reason := c.Day(day)
+		if !ok {
+			skipped = append(skipped, models.SkippedSession{
+				Date:   day.Format("2006-01-02"),
+				Reason: reason,
+			})
+			continue
+		}
+
+		if _, _, overlaps := s.Clamp(from, to); overlaps {
+			sessions = append(sessions, s)
+		}
+	}
+
+	return sessions, skipped
+}
+
+// Covers reports whether day is inside the dates the calendar's holidays
+// are maintained for.
+func (c *Calendar) Covers(day time.Time) bool {
+	key := day.In(c.loc).Format("2006-01-02")
+	return key >= c.coverFrom && key <= c.coverTo
+}
+
+// CoverageStart is midnight of the first day the calendar covers.
+func (c *Calendar) CoverageStart() time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", c.coverFrom, c.loc)
+	return t
+}
+
+// Location is the exchange timezone.
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
@@ -0,0 +1,162 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// Anchors of a futures expiry rule: the day of the contract month the
+// expiry is counted back from.
+const (
+	anchorWeekday  = "weekday"   // last monthly expiry weekday of the month
+	anchorDay      = "day"       // a fixed day of the month
+	anchorMonthEnd = "month_end" // last day of the month
+)
+
+// futuresExpiry is the expiry rule of an underlying's futures. The
+// contract expires on the last regular session on or before the anchor,
+// moved back Before more sessions. Months lists the contract months,
+// every month when empty.
+type futuresExpiry struct {
+	Anchor string `json:"anchor"`
+	Day    int    `json:"day,omitempty"`
+	Before int    `json:"before,omitempty"`
+	Months []int  `json:"months,omitempty"`
+}
+
+func (f futuresExpiry) validate() error {
+	switch f.Anchor {
+	case anchorWeekday, anchorMonthEnd:
+	case anchorDay:
+		if f.Day < 1 || f.Day > 31 {
+			return fmt.Errorf("futures expiry day %d", f.Day)
+		}
+	default:
+		return fmt.Errorf("futures expiry anchor %q (weekday, day, month_end)", f.Anchor)
+	}
+
+	if f.Before < 0 {
+		return fmt.Errorf("futures expiry before %d", f.Before)
+	}
+
+	for _, m := range f.Months {
+		if m < 1 || m > 12 {
+			return fmt.Errorf("futures expiry month %d", m)
+		}
+	}
+
+	return nil
+}
+
+// HasFuturesExpiries reports whether the registry has a futures expiry
+// rule for the underlying.
+func HasFuturesExpiries(underlying string) bool {
+	_, ok := registry.futuresExpiry[underlying]
+	return ok
+}
+
+// FuturesExpiries returns the futures expiry of every contract month from
+// the month of from through the month of to, in order. Holidays and
+// weekends move an expiry to the previous regular session; special
+// sessions (Muhurat, DR drills) never hold one.
+func FuturesExpiries(underlying string, from, to time.Time) ([]time.Time, error) {
+	rule, ok := registry.futuresExpiry[underlying]
+	if !ok {
+		return nil, fmt.Errorf("no futures expiry rule for %s", underlying)
+	}
+
+	cal := For(underlying)
+	loc := cal.Location()
+
+	from = from.In(loc)
+	to = to.In(loc)
+
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, loc)
+	last := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, loc)
+
+	expiries := []time.Time{}
+
+	for ; !month.After(last); month = month.AddDate(0, 1, 0) {
+		if !rule.lists(month.Month()) {
+			continue
+		}
+
+		monthEnd := month.AddDate(0, 1, -1)
+
+		var anchor time.Time
+		switch rule.Anchor {
+		case anchorMonthEnd:
+			anchor = monthEnd
+		case anchorDay:
+			anchor = month.AddDate(0, 0, rule.Day-1)
+			if anchor.After(monthEnd) {
+				anchor = monthEnd
+			}
+		case anchorWeekday:
+			var ok bool
+			if anchor, ok = monthlyWeekday(underlying, month); !ok {
+				continue
+			}
+		}
+
+		day := cal.regularOnOrBefore(anchor)
+		for n := 0; n < rule.Before; n++ {
+			day = cal.regularOnOrBefore(day.AddDate(0, 0, -1))
+		}
+
+		expiries = append(expiries, day)
+	}
+
+	return expiries, nil
+}
+
+// MonthlyExpiry returns the monthly option expiry of month: the last
+// monthly expiry weekday of the month, moved to the previous regular
+// session when the exchange is closed or only holds a special session.
+// ok is false without a weekday schedule for the month.
+func MonthlyExpiry(underlying string, month time.Time) (time.Time, bool) {
+	cal := For(underlying)
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, cal.Location())
+
+	day, ok := monthlyWeekday(underlying, first)
+	if !ok {
+		return time.Time{}, false
+	}
+	return cal.regularOnOrBefore(day), true
+}
+
+// monthlyWeekday returns the last monthly expiry weekday of month's
+// calendar month.
+func monthlyWeekday(underlying string, month time.Time) (time.Time, bool) {
+	monthEnd := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, month.Location())
+
+	wd, ok := ExpiryWeekday(underlying, monthEnd, true)
+	if !ok {
+		return time.Time{}, false
+	}
+	return monthEnd.AddDate(0, 0, -((int(monthEnd.Weekday()) - int(wd) + 7) % 7)), true
+}
+
+func (f futuresExpiry) lists(m time.Month) bool {
+	if len(f.Months) == 0 {
+		return true
+	}
+	for _, listed := range f.Months {
+		if time.Month(listed) == m {
+			return true
+		}
+	}
+	return false
+}
+
+// regularOnOrBefore returns the last regular or early-close session day
+// on or before day.
+func (c *Calendar) regularOnOrBefore(day time.Time) time.Time {
+	for {
+		if s, ok, _ := c.Day(day); ok && s.Kind != "special" {
+			return s.Date
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+}
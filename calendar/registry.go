@@ -0,0 +1,182 @@
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+type registryFile struct {
+	DefaultExchange string                    `json:"default_exchange"`
+	Exchanges       map[string]exchangeFile   `json:"exchanges"`
+	Underlyings     map[string]underlyingFile `json:"underlyings"`
+}
+
+type underlyingFile struct {
+	Exchange       string          `json:"exchange"`
+	ExpiryWeekdays []expiryWeekday `json:"expiry_weekdays,omitempty"`
+	FuturesExpiry  *futuresExpiry  `json:"futures_expiry,omitempty"`
+}
+
+// expiryWeekday is the regular expiry weekday of an underlying's
+// contracts from a date on. Monthly is set when monthly contracts expire
+// on a different weekday than weekly ones.
+type expiryWeekday struct {
+	From    string `json:"from"`
+	Weekday string `json:"weekday"`
+	Monthly string `json:"monthly,omitempty"`
+
+	weekly, monthly time.Weekday
+}
+
+// Registry maps underlyings to the exchange whose sessions they trade in.
+type Registry struct {
+	exchanges   map[string]*Calendar
+	underlyings map[string]string
+	fallback    *Calendar
+
+	expiryWeekdays map[string][]expiryWeekday // by From
+	futuresExpiry  map[string]futuresExpiry
+}
+
+var registry *Registry
+
+// Load reads the exchange registry and calendars from a JSON file. It
+// must be called once at startup, before any session lookups.
+func Load(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var f registryFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return fmt.Errorf("calendar %s: %w", path, err)
+	}
+
+	r, err := parseRegistry(f)
+	if err != nil {
+		return fmt.Errorf("calendar %s: %w", path, err)
+	}
+
+	registry = r
+	return nil
+}
+
+func parseRegistry(f registryFile) (*Registry, error) {
+	r := &Registry{
+		exchanges:   map[string]*Calendar{},
+		underlyings: map[string]string{},
+
+		expiryWeekdays: map[string][]expiryWeekday{},
+		futuresExpiry:  map[string]futuresExpiry{},
+	}
+
+	for code, ef := range f.Exchanges {
+		c, err := parse(code, ef)
+		if err != nil {
+			return nil, fmt.Errorf("exchange %s: %w", code, err)
+		}
+		r.exchanges[code] = c
+	}
+
+	for underlying, uf := range f.Underlyings {
+		if _, ok := r.exchanges[uf.Exchange]; !ok {
+			return nil, fmt.Errorf("underlying %s: unknown exchange %q", underlying, uf.Exchange)
+		}
+		r.underlyings[underlying] = uf.Exchange
+
+		schedule := make([]expiryWeekday, len(uf.ExpiryWeekdays))
+		for i, e := range uf.ExpiryWeekdays {
+			if _, err := time.Parse("2006-01-02", e.From); err != nil {
+				return nil, fmt.Errorf("underlying %s: expiry weekday from %q: %w", underlying, e.From, err)
+			}
+
+			wd, err := parseWeekday(e.Weekday)
+			if err != nil {
+				return nil, fmt.Errorf("underlying %s: %w", underlying, err)
+			}
+			e.weekly, e.monthly = wd, wd
+
+			if e.Monthly != "" {
+				if e.monthly, err = parseWeekday(e.Monthly); err != nil {
+					return nil, fmt.Errorf("underlying %s: %w", underlying, err)
+				}
+			}
+
+			schedule[i] = e
+		}
+		sort.Slice(schedule, func(i, j int) bool { return schedule[i].From < schedule[j].From })
+
+		if len(schedule) > 0 {
+			r.expiryWeekdays[underlying] = schedule
+		}
+
+		if fe := uf.FuturesExpiry; fe != nil {
+			if err := fe.validate(); err != nil {
+				return nil, fmt.Errorf("underlying %s: %w", underlying, err)
+			}
+			if fe.Anchor == anchorWeekday && len(schedule) == 0 {
+				return nil, fmt.Errorf("underlying %s: futures expiry anchor weekday needs expiry_weekdays", underlying)
+			}
+			r.futuresExpiry[underlying] = *fe
+		}
+	}
+
+	fallback, ok := r.exchanges[f.DefaultExchange]
+	if !ok {
+		return nil, fmt.Errorf("unknown default_exchange %q", f.DefaultExchange)
+	}
+	r.fallback = fallback
+
+	return r, nil
+}
+
+// For returns the calendar of the exchange the underlying trades on.
+// Underlyings missing from the registry use the default exchange.
+func For(underlying string) *Calendar {
+	if code, ok := registry.underlyings[underlying]; ok {
+		return registry.exchanges[code]
+	}
+	return registry.fallback
+}
+
+// ExpiryWeekday returns the regular weekday the underlying's weekly or
+// monthly contracts expire on, as of day. ok is false when the registry
+// has no schedule covering day.
+func ExpiryWeekday(underlying string, day time.Time, monthly bool) (time.Weekday, bool) {
+	schedule := registry.expiryWeekdays[underlying]
+	key := day.Format("2006-01-02")
+
+	i := sort.Search(len(schedule), func(i int) bool { return schedule[i].From > key })
+	if i == 0 {
+		return 0, false
+	}
+
+	if monthly {
+		return schedule[i-1].monthly, true
+	}
+	return schedule[i-1].weekly, true
+}
+
+// ExpiryShifted reports whether an expiry on day was moved off its
+// regular weekday because the exchange was closed that day; expiries move
+// to the previous session. A day with only a special session (Muhurat)
+// counts as closed, as it never holds an expiry. known is false without a
+// weekday schedule.
+func ExpiryShifted(underlying string, day time.Time, monthly bool) (shifted, known bool) {
+	wd, ok := ExpiryWeekday(underlying, day, monthly)
+	if !ok {
+		return false, false
+	}
+	if day.Weekday() == wd {
+		return false, true
+	}
+
+	regular := day.AddDate(0, 0, (int(wd)-int(day.Weekday())+7)%7)
+	s, open, _ := For(underlying).Day(regular)
+
+	return !open || s.Kind == "special", true
+}
@@ -0,0 +1,74 @@
+package calendar
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	if err := Load("../config/calendar.json"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func date(s string) time.Time {
+	d, err := time.ParseInLocation("2006-01-02", s, For("NIFTY").Location())
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestExpiryShifted(t *testing.T) {
+	tests := []struct {
+		name    string
+		day     string
+		monthly bool
+		want    bool
+	}{
+		// Tuesday 2025-10-21 held only the Muhurat session
+		{"moved off a muhurat day", "2025-10-20", false, true},
+		{"on its weekday", "2025-10-14", false, false},
+		{"monthly on its weekday", "2025-10-28", true, false},
+		{"off its weekday with the weekday open", "2025-10-13", false, false},
+		// the Thursday schedule before September 2025
+		{"thursday schedule", "2025-08-14", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shifted, known := ExpiryShifted("NIFTY", date(tt.day), tt.monthly)
+			if !known {
+				t.Fatalf("known false, want true")
+			}
+			if shifted != tt.want {
+				t.Errorf("shifted %v, want %v", shifted, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthlyExpiry(t *testing.T) {
+	tests := []struct {
+		month string
+		want  string
+	}{
+		{"2025-08-01", "2025-08-28"},
+		{"2025-10-01", "2025-10-28"},
+		{"2025-11-01", "2025-11-25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.month, func(t *testing.T) {
+			got, ok := MonthlyExpiry("NIFTY", date(tt.month))
+			if !ok {
+				t.Fatalf("ok false, want true")
+			}
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("expiry %s, want %s", got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
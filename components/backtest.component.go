@@ -0,0 +1,173 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"quant-read-api/backtest"
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+)
+
+// BacktestLeg is one leg of a backtested strategy, selected afresh every
+// day at entry.
+type BacktestLeg struct {
+	OptionType   string
+	Side         string
+	Quantity     int
+	Moneyness    string // ATM | ITM | OTM, with MoneynessLvl
+	MoneynessLvl int
+	Premium      float64 // when set, the strike priced closest to it instead
+}
+
+// BacktestSpec is a declarative intraday strategy over a range of days.
+type BacktestSpec struct {
+	Underlying string
+	Expiry     ExpiryRule // resolved per session
+	From       time.Time  // first trading day
+	To         time.Time  // last trading day
+	EntryTime  time.Duration
+	ExitTime   time.Duration // since midnight, clamped to the session close
+	Legs       []BacktestLeg
+	Rules      backtest.Rules
+}
+
+// GetBacktest runs a strategy over every session from From to To. Each
+// day the legs are selected as of the entry time, marked on their own
+// prints until the exit time and closed on the first rule that fires.
+// Days the strategy cannot be put on are reported as skipped.
+func GetBacktest(spec BacktestSpec) (models.Backtest, error) {
+	out := models.Backtest{
+		Trades:  []models.BacktestTrade{},
+		Skipped: []models.SkippedSession{},
+	}
+
+	cal := calendar.For(spec.Underlying)
+
+	sessions, _ := cal.Sessions(spec.From, spec.To.AddDate(0, 0, 1))
+
+	expiries, err := sessionExpiries(spec.Underlying, sessions, spec.Expiry)
+	if err != nil {
+		return out, err
+	}
+
+	for i, s := range sessions {
+		date := s.Date.Format("2006-01-02")
+
+		skip := func(reason string) {
+			out.Skipped = append(out.Skipped, models.SkippedSession{Date: date, Reason: reason})
+		}
+
+		entry := s.Date.Add(spec.EntryTime)
+		exit := s.Date.Add(spec.ExitTime)
+		if exit.After(s.Close) {
+			exit = s.Close
+		}
+
+		if entry.Before(s.Open) || !entry.Before(exit) {
+			skip("entry outside session")
+			continue
+		}
+
+		if expiries[i] == "" {
+			skip("no expiry listed")
+			continue
+		}
+
+		positions, ticks, reason, err := loadBacktestDay(spec, s, expiries[i], entry, exit)
+		if err != nil {
+			return out, err
+		}
+		if reason != "" {
+			skip(reason)
+			continue
+		}
+
+		out.Trades = append(out.Trades, backtest.RunDay(date, positions, ticks, spec.Rules)...)
+	}
+
+	out.Daily, out.Summary = backtest.Summarize(out.Trades)
+
+	return out, nil
+}
+
+// loadBacktestDay selects every leg's contract at entry and reads their
+// prints up to exit, merged in time order. reason is set when the day
+// cannot be traded.
+func loadBacktestDay(
+	spec BacktestSpec,
+	session calendar.Session,
+	expiry string,
+	entry time.Time,
+	exit time.Time,
+) ([]backtest.Position, []backtest.Tick, string, error) {
+
+	expiryDate, err := time.ParseInLocation("2006-01-02", expiry, session.Date.Location())
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	positions := make([]backtest.Position, len(spec.Legs))
+	ticks := []backtest.Tick{}
+
+	for i, leg := range spec.Legs {
+		var strike uint32
+
+		if leg.Premium > 0 {
+			strike, err = premiumStrike(spec.Underlying, expiryDate, leg.OptionType, leg.Premium, entry)
+		} else {
+			strike, err = sliceStrike(spec.Underlying, expiry, leg.OptionType, leg.Moneyness, leg.MoneynessLvl, session, entry, exit)
+		}
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if strike == 0 {
+			return nil, nil, fmt.Sprintf("leg %d: no strike selected", i+1), nil
+		}
+
+		positions[i] = backtest.Position{
+			Expiry:     expiry,
+			Strike:     strike,
+			OptionType: leg.OptionType,
+			Side:       leg.Side,
+			Quantity:   leg.Quantity,
+		}
+
+		data, err := GetOptionContract(spec.Underlying, ExpiryOn(expiry), strike, leg.OptionType, entry, exit, nil, nil, false)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		rows := data.([]models.OptionContractRow)
+		if len(rows) == 0 {
+			return nil, nil, fmt.Sprintf("leg %d: no prints", i+1), nil
+		}
+
+		for _, r := range rows {
+			ticks = append(ticks, backtest.Tick{Ts: r.Ts, Leg: i, Price: r.Ltp, Spot: r.SpotPrice})
+		}
+	}
+
+	// legs were appended in order, so ties keep leg order
+	sort.SliceStable(ticks, func(a, b int) bool { return ticks[a].Ts.Before(ticks[b].Ts) })
+
+	return positions, ticks, "", nil
+}
+
+// premiumStrike returns the strike of expiry picked by premium as of at,
+// with the tie-breaks of the daily selection, or 0 when nothing of
+// optionType has printed yet that session.
+func premiumStrike(underlying string, expiry time.Time, optionType string, premium float64, at time.Time) (uint32, error) {
+	quotes, err := loadChainQuotes(underlying, &expiry, at)
+	if err != nil {
+		return 0, err
+	}
+
+	pick, ok := pickByPremium(quotes, optionType, premium, freshest(quotes).AtmStrike)
+	if !ok {
+		return 0, nil
+	}
+
+	return pick.Quote.Strike, nil
+}
@@ -0,0 +1,131 @@
+package components
+
+import (
+	"time"
+
+	"quant-read-api/bars"
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// Bar construction modes.
+const (
+	BarsTime       = "time"        // time-bucketed candles (default)
+	BarsHeikinAshi = "heikin_ashi" // Heikin-Ashi over time candles
+	BarsRenko      = "renko"       // fixed bricks, BarSize points or percent
+	BarsRange      = "range"       // bars spanning at most BarSize points
+	BarsTick       = "tick"        // bars of BarSize ticks
+)
+
+// buildBars dispatches a resample request to the bar type it asks for.
+// Every bar type reads the same source the time candles do.
+func buildBars(
+	src resampleSource,
+	cal *calendar.Calendar,
+	from time.Time,
+	to time.Time,
+	spec ResampleSpec,
+) (models.ColumnarOHLC, error) {
+
+	switch spec.Bars {
+	case BarsHeikinAshi:
+		candles, err := resampleOHLC(src, cal, from, to, spec)
+		if err != nil {
+			return candles, err
+		}
+		return bars.HeikinAshi(candles), nil
+
+	case BarsRenko, BarsRange, BarsTick:
+		ticks, err := loadTicks(src, cal, from, to)
+		if err != nil {
+			return models.ColumnarOHLC{}, err
+		}
+
+		switch spec.Bars {
+		case BarsRenko:
+			return bars.Renko(ticks, spec.BarSize, spec.BarPercent), nil
+		case BarsRange:
+			return bars.Range(ticks, spec.BarSize), nil
+		default:
+			return bars.TickCount(ticks, int(spec.BarSize)), nil
+		}
+
+	default:
+		return resampleOHLC(src, cal, from, to, spec)
+	}
+}
+
+// loadTicks reads the raw second prices inside the trading sessions of
+// [from, to), tagged with their session.
+func loadTicks(
+	src resampleSource,
+	cal *calendar.Calendar,
+	from time.Time,
+	to time.Time,
+) ([]bars.Tick, error) {
+
+	windows := sessionWindows(cal, from, to)
+	if len(windows) == 0 {
+		return []bars.Tick{}, nil
+	}
+
+	days := make([]uint32, 0, len(windows))
+	effectiveStarts := make([]int64, 0, len(windows))
+	effectiveEnds := make([]int64, 0, len(windows))
+
+	for _, w := range windows {
+		days = append(days, yyyymmdd(w.Date))
+		effectiveStarts = append(effectiveStarts, w.EffectiveStart.Unix())
+		effectiveEnds = append(effectiveEnds, w.EffectiveEnd.Unix())
+	}
+
+	tz := cal.Location().String()
+
+	query := `
+	WITH
+		? AS session_days,
+		? AS effective_starts,
+		? AS effective_ends
+	SELECT
+		ts,
+		` + src.Price + ` AS price,
+		indexOf(session_days, toYYYYMMDD(ts, '` + tz + `')) AS session_idx
+	FROM ` + src.Table + `
+	WHERE ` + src.Filter + `
+	  AND ts >= ?
+	  AND ts < ?
+	  AND session_idx > 0
+	  AND toInt64(toUnixTimestamp(ts)) >= effective_starts[session_idx]
+	  AND toInt64(toUnixTimestamp(ts)) < effective_ends[session_idx]
+	ORDER BY ts
+	`
+
+	args := []any{days, effectiveStarts, effectiveEnds}
+	args = append(args, src.PriceArgs...)
+	args = append(args, src.TableArgs...)
+	args = append(args, src.Args...)
+	args = append(args, windows[0].EffectiveStart, windows[len(windows)-1].EffectiveEnd)
+
+	rows, err := services.GetClickHouse().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []bars.Tick{}
+
+	for rows.Next() {
+		var t bars.Tick
+		var session uint64
+
+		if err := rows.Scan(&t.Ts, &t.Price, &session); err != nil {
+			return nil, err
+		}
+
+		t.Session = int(session)
+		out = append(out, t)
+	}
+
+	return out, rows.Err()
+}
@@ -0,0 +1,106 @@
+package components
+
+import (
+	"fmt"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/services"
+)
+
+// calendarCheckTables hold prints that are only ever read through the
+// sessions of the trading calendar.
+var calendarCheckTables = []string{
+	"second_data.index_data",
+	"second_data.futures_data",
+	"options_moneyness",
+}
+
+// CheckCalendar lists every day on which a table holds prints of an
+// underlying while its exchange calendar has no session, or does not
+// cover the day. Prints on a closed day are dropped by everything that
+// walks sessions: bars, chains and expiry filters alike. Uncovered days
+// are served on the regular weekday session, with no holidays known.
+func CheckCalendar() ([]string, error) {
+	db := services.GetClickHouse()
+
+	missing := []string{}
+
+	for _, table := range calendarCheckTables {
+		rows, err := db.Query(`SELECT DISTINCT underlying FROM ` + table + ` ORDER BY underlying`)
+		if err != nil {
+			return nil, err
+		}
+
+		underlyings := []string{}
+		for rows.Next() {
+			var u string
+			if err := rows.Scan(&u); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			underlyings = append(underlyings, u)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, u := range underlyings {
+			cal := calendar.For(u)
+			loc := cal.Location()
+
+			days, err := tradingDays(table, u, loc)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, day := range days {
+				if !cal.Covers(day) {
+					missing = append(missing, fmt.Sprintf(
+						"%s %s %s: prints outside the %s calendar coverage",
+						table, u, day.Format("2006-01-02"), cal.Exchange,
+					))
+					continue
+				}
+				if _, ok, reason := cal.Day(day); !ok {
+					missing = append(missing, fmt.Sprintf(
+						"%s %s %s: prints on a day the %s calendar closes (%s)",
+						table, u, day.Format("2006-01-02"), cal.Exchange, reason,
+					))
+				}
+			}
+		}
+	}
+
+	return missing, nil
+}
+
+// tradingDays returns the local dates on which table has prints of
+// underlying.
+func tradingDays(table string, underlying string, loc *time.Location) ([]time.Time, error) {
+	query := `
+		SELECT DISTINCT toYYYYMMDD(ts, '` + loc.String() + `') AS day
+		FROM ` + table + `
+		WHERE underlying = ?
+		ORDER BY day
+	`
+
+	rows, err := services.GetClickHouse().Query(query, underlying)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []time.Time{}
+
+	for rows.Next() {
+		var d uint32
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		days = append(days, time.Date(int(d/10000), time.Month(d/100%100), int(d%100), 0, 0, 0, 0, loc))
+	}
+
+	return days, rows.Err()
+}
@@ -0,0 +1,286 @@
+package components
+
+import (
+	"fmt"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// Roll rules for continuous futures.
+const (
+	RollExpiry    = "expiry"    // roll at the close of the expiry session
+	RollBefore    = "before"    // roll at the close N sessions before expiry
+	RollCrossover = "crossover" // next contract out-trades the near one: unsupported, no volume or OI
+)
+
+// Back-adjustment methods applied to prices before each roll.
+const (
+	AdjustNone       = "none"       // stitch only, gaps stay in the series
+	AdjustDifference = "difference" // shift earlier prices by next - near
+	AdjustRatio      = "ratio"      // scale earlier prices by next / near
+)
+
+// RollRule decides when the continuous series moves to the next contract.
+type RollRule struct {
+	Mode string
+	Days int // sessions before expiry, for RollBefore
+}
+
+// NextSeries names the contract after series in futures_data, the one a
+// continuous series rolls into.
+func NextSeries(series string) (string, error) {
+	switch series {
+	case "near":
+		return "next", nil
+	case "next":
+		return "far", nil
+	case "1":
+		return "2", nil
+	case "2":
+		return "3", nil
+	}
+	return "", fmt.Errorf("series %s has no next contract to roll into", series)
+}
+
+// GetContinuousFutures stitches series with the contract after it across
+// every futures expiry in [from, to), back-adjusting earlier prices so the
+// series has no jump at the roll. It returns raw rows or candles, like
+// GetFuturesData, plus the rolls it applied.
+func GetContinuousFutures(
+	underlying string,
+	series string,
+	from time.Time,
+	to time.Time,
+	roll RollRule,
+	adjust string,
+	spec *ResampleSpec,
+) (any, []models.FuturesRoll, error) {
+
+	next, err := NextSeries(series)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cal := calendar.For(underlying)
+
+	// the contract rolled into before to may expire the month after
+	expiries, err := calendar.FuturesExpiries(underlying, from, to.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type rollPoint struct {
+		expiry  time.Time
+		session calendar.Session // session whose close is the roll
+		at      time.Time        // roll time: next contract from here
+		until   time.Time        // expiry close: near contract is next again
+	}
+
+	points := []rollPoint{}
+
+	for _, expiry := range expiries {
+		expirySession, ok, _ := cal.Day(expiry)
+		if !ok {
+			continue
+		}
+
+		rollSession := expirySession
+		if roll.Mode == RollBefore {
+			day := expirySession.Date
+			for n := 0; n < roll.Days; {
+				day = day.AddDate(0, 0, -1)
+				if s, ok, _ := cal.Day(day); ok {
+					rollSession = s
+					n++
+				}
+			}
+		}
+
+		if !expirySession.Close.After(from) || !rollSession.Close.Before(to) {
+			continue
+		}
+
+		points = append(points, rollPoint{
+			expiry:  expiry,
+			session: rollSession,
+			at:      rollSession.Close,
+			until:   expirySession.Close,
+		})
+	}
+
+	rolls := make([]models.FuturesRoll, len(points))
+	for i, p := range points {
+		rolls[i] = models.FuturesRoll{
+			Ts:     p.at.Format(time.RFC3339),
+			Expiry: p.expiry.Format("2006-01-02"),
+		}
+	}
+
+	src := resampleSource{
+		Table:  "second_data.futures_data",
+		Price:  "futures_price",
+		Filter: "underlying = ? AND series = ?",
+		Args:   []any{underlying, series},
+	}
+
+	if len(points) > 0 {
+		db := services.GetClickHouse()
+
+		// last near and next prices of every roll session
+		days := make([]uint32, len(points))
+		for i, p := range points {
+			days[i] = yyyymmdd(p.session.Date)
+		}
+
+		query := `
+			SELECT
+				toYYYYMMDD(ts, '` + cal.Location().String() + `') AS day,
+				series,
+				argMax(futures_price, ts) AS last_price
+			FROM second_data.futures_data
+			WHERE underlying = ?
+			  AND series IN (?, ?)
+			  AND ts >= ?
+			  AND ts < ?
+			  AND has(?, day)
+			GROUP BY day, series
+		`
+
+		rows, err := db.Query(
+			query,
+			underlying,
+			series,
+			next,
+			points[0].session.Open,
+			points[len(points)-1].at,
+			days,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		last := map[string]float64{}
+		for rows.Next() {
+			var day uint32
+			var s string
+			var price float64
+			if err := rows.Scan(&day, &s, &price); err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+			last[fmt.Sprintf("%d/%s", day, s)] = price
+		}
+		rows.Close()
+
+		rollTimes := make([]int64, len(points))
+		untilTimes := make([]int64, len(points))
+
+		// adjustments[k] applies to ticks after k rolls: the combined
+		// gap of every later roll, so the latest contract is unadjusted
+		adjustments := make([]float64, len(points)+1)
+		if adjust == AdjustRatio {
+			adjustments[len(points)] = 1
+		}
+
+		for i := len(points) - 1; i >= 0; i-- {
+			p := points[i]
+			rollTimes[i] = p.at.Unix()
+			untilTimes[i] = p.until.Unix()
+
+			nearPrice, okNear := last[fmt.Sprintf("%d/%s", days[i], series)]
+			nextPrice, okNext := last[fmt.Sprintf("%d/%s", days[i], next)]
+
+			rolls[i].Near = nearPrice
+			rolls[i].Next = nextPrice
+
+			switch {
+			case adjust == AdjustRatio && okNear && okNext && nearPrice != 0:
+				rolls[i].Adjustment = nextPrice / nearPrice
+				adjustments[i] = adjustments[i+1] * rolls[i].Adjustment
+			case adjust == AdjustRatio:
+				rolls[i].Adjustment = 1
+				adjustments[i] = adjustments[i+1]
+			case adjust == AdjustDifference && okNear && okNext:
+				rolls[i].Adjustment = nextPrice - nearPrice
+				adjustments[i] = adjustments[i+1] + rolls[i].Adjustment
+			default:
+				adjustments[i] = adjustments[i+1]
+			}
+		}
+
+		// between a roll and its expiry close the series reads the next
+		// contract; everywhere else the near one
+		src.Filter = `underlying = ?
+			  AND series IN (?, ?)
+			  AND (series = ?) = arrayExists(
+				(r, u) -> toInt64(toUnixTimestamp(ts)) >= r AND toInt64(toUnixTimestamp(ts)) < u,
+				?, ?
+			  )`
+		src.Args = []any{underlying, series, next, next, rollTimes, untilTimes}
+
+		switch adjust {
+		case AdjustDifference:
+			src.Price = `futures_price + arrayElement(?, arrayCount(r -> r <= toInt64(toUnixTimestamp(ts)), ?) + 1)`
+			src.PriceArgs = []any{adjustments, rollTimes}
+		case AdjustRatio:
+			src.Price = `futures_price * arrayElement(?, arrayCount(r -> r <= toInt64(toUnixTimestamp(ts)), ?) + 1)`
+			src.PriceArgs = []any{adjustments, rollTimes}
+		}
+	}
+
+	// report only the rolls that happen inside the range
+	reported := []models.FuturesRoll{}
+	for i, p := range points {
+		if !p.at.Before(from) {
+			reported = append(reported, rolls[i])
+		}
+	}
+
+	if spec != nil {
+		data, err := buildBars(src, cal, from, to, *spec)
+		return data, reported, err
+	}
+
+	query := `
+		SELECT
+			ts,
+			` + src.Price + ` AS price,
+			underlying,
+			series
+		FROM ` + src.Table + `
+		WHERE ` + src.Filter + `
+		  AND ts >= ?
+		  AND ts < ?
+		ORDER BY ts
+	`
+
+	args := append([]any{}, src.PriceArgs...)
+	args = append(args, src.Args...)
+	args = append(args, from, to)
+
+	rows, err := services.GetClickHouse().Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.FuturesDataRow, 0)
+
+	for rows.Next() {
+		var r models.FuturesDataRow
+		if err := rows.Scan(
+			&r.Ts,
+			&r.FuturesPrice,
+			&r.Underlying,
+			&r.Series,
+		); err != nil {
+			return nil, nil, err
+		}
+		out = append(out, r)
+	}
+
+	return out, reported, rows.Err()
+}
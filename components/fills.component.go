@@ -0,0 +1,278 @@
+package components
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/execution"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// Order types and fill statuses.
+const (
+	OrderMarket = "market"
+	OrderLimit  = "limit"
+
+	FillFilled   = "filled"
+	FillUnfilled = "unfilled"
+)
+
+// Order is one historical order to fill.
+type Order struct {
+	Underlying string
+	Instrument string     // CE | PE | FUT
+	Expiry     ExpiryRule // options, resolved on the day of Ts
+	Strike     uint32     // options
+	Series     string     // futures
+	Ts         time.Time
+	Side       string
+	Quantity   int
+	Type       string
+	LimitPrice float64
+	ValidUntil time.Time // limit orders; zero means the session close
+}
+
+// FillConfig is the execution model orders are filled under.
+type FillConfig struct {
+	Latency  time.Duration
+	Slippage execution.Slippage
+	TickSize float64
+	Charges  execution.Schedule
+}
+
+// SimulateFills fills every order against the recorded second prices of
+// its contract, within the session it was placed in.
+//
+// An order arrives Latency after its time and meets the first print from
+// then on. A market order fills at that print after slippage, rounded to
+// the tick against it. A limit order that is marketable on arrival fills
+// the same way, capped at its limit; otherwise it rests and fills at its
+// limit on the first print that reaches it before it expires.
+func SimulateFills(orders []Order, cfg FillConfig) (models.FillReport, error) {
+	out := models.FillReport{
+		Fills: make([]models.Fill, 0, len(orders)),
+	}
+
+	// orders on the same rule and day share one expiry lookup
+	resolved := map[string]string{}
+
+	for i, o := range orders {
+		expiry := ""
+		if o.Instrument != InstrumentFutures {
+			key := o.Underlying + "/" + o.Ts.Format("2006-01-02") + "/" + o.Expiry.String()
+
+			var ok bool
+			if expiry, ok = resolved[key]; !ok {
+				var err error
+				if expiry, err = ResolveExpiry(o.Underlying, o.Ts, o.Expiry); err != nil {
+					return out, err
+				}
+				resolved[key] = expiry
+			}
+		}
+
+		f, err := simulateFill(o, expiry, cfg)
+		if err != nil {
+			return out, err
+		}
+		f.Order = i + 1
+
+		out.Totals.Orders++
+		if f.Status == FillFilled {
+			out.Totals.Filled++
+			out.Totals.Turnover += f.Turnover
+			out.Totals.Charges.Add(f.Charges)
+
+			if f.Slippage != nil {
+				out.Totals.SlippageCost += *f.Slippage * float64(o.Quantity)
+			}
+		}
+
+		out.Fills = append(out.Fills, f)
+	}
+
+	return out, nil
+}
+
+// simulateFill fills one order; options orders fill on expiry, the one
+// their rule resolved to.
+func simulateFill(o Order, expiry string, cfg FillConfig) (models.Fill, error) {
+	buy := o.Side == SideBuy
+
+	// prints are per second: an order arriving mid-second meets the next one
+	arrival := o.Ts.Add(cfg.Latency)
+	if t := arrival.Truncate(time.Second); !t.Equal(arrival) {
+		arrival = t.Add(time.Second)
+	}
+
+	f := models.Fill{
+		Status:    FillUnfilled,
+		ArrivalTs: arrival,
+	}
+
+	if o.Instrument != InstrumentFutures {
+		if expiry == "" {
+			f.Reason = "no expiry matching " + o.Expiry.String() + " listed"
+			return f, nil
+		}
+		f.Expiry = expiry
+	}
+
+	session, ok := sessionAsOf(calendar.For(o.Underlying), o.Ts)
+	if !ok || !arrival.Before(session.Close) {
+		f.Reason = "outside session"
+		return f, nil
+	}
+
+	end := session.Close
+	if o.Type == OrderLimit && !o.ValidUntil.IsZero() && o.ValidUntil.Before(end) {
+		end = o.ValidUntil
+	}
+
+	src := orderSource(o, expiry)
+
+	_, ref, ok, err := lastPrint(src, session.Open, o.Ts)
+	if err != nil {
+		return f, err
+	}
+	if ok {
+		f.ReferencePrice = optionalFloat(ref)
+	}
+
+	fillTs, market, ok, err := firstPrint(src, arrival, end, "1")
+	if err != nil {
+		return f, err
+	}
+	if !ok {
+		f.Reason = "no prints"
+		return f, nil
+	}
+
+	price := execution.RoundToTick(cfg.Slippage.Apply(market, buy), cfg.TickSize, buy)
+
+	if o.Type == OrderLimit {
+		marketable := (buy && market <= o.LimitPrice) || (!buy && market >= o.LimitPrice)
+
+		switch {
+		case marketable && buy:
+			price = min(price, o.LimitPrice)
+		case marketable:
+			price = max(price, o.LimitPrice)
+
+		default:
+			cond := "price <= ?"
+			if !buy {
+				cond = "price >= ?"
+			}
+
+			fillTs, market, ok, err = firstPrint(src, fillTs.Add(time.Second), end, cond, o.LimitPrice)
+			if err != nil {
+				return f, err
+			}
+			if !ok {
+				f.Reason = "limit not reached"
+				return f, nil
+			}
+
+			price = o.LimitPrice
+		}
+	}
+
+	f.Status = FillFilled
+	f.MarketPrice = optionalFloat(market)
+	f.FillTs = fillTs.Format(time.RFC3339)
+	f.FillPrice = optionalFloat(price)
+
+	if f.ReferencePrice != nil {
+		slippage := price - *f.ReferencePrice
+		if !buy {
+			slippage = -slippage
+		}
+		f.Slippage = optionalFloat(slippage)
+	}
+
+	f.Turnover = price * float64(o.Quantity)
+	f.Charges = cfg.Charges.Costs(o.Instrument == InstrumentFutures, buy, price, o.Quantity)
+
+	return f, nil
+}
+
+// orderSource is where an order's contract prints.
+func orderSource(o Order, expiry string) resampleSource {
+	if o.Instrument == InstrumentFutures {
+		return resampleSource{
+			Table:  "second_data.futures_data",
+			Price:  "futures_price",
+			Filter: "underlying = ? AND series = ?",
+			Args:   []any{o.Underlying, o.Series},
+		}
+	}
+
+	return resampleSource{
+		Table: "options_moneyness",
+		Price: "ltp",
+		Filter: `underlying = ?
+			  AND expiry = toDate(?)
+			  AND strike = ?
+			  AND option_type = ?`,
+		Args: []any{o.Underlying, expiry, o.Strike, o.Instrument},
+	}
+}
+
+// firstPrint returns the first print of src in [from, to) meeting cond,
+// a condition on price.
+func firstPrint(src resampleSource, from, to time.Time, cond string, condArgs ...any) (time.Time, float64, bool, error) {
+	query := `
+		SELECT ts, price
+		FROM
+		(
+			SELECT ts, ` + src.Price + ` AS price
+			FROM ` + src.Table + `
+			WHERE ` + src.Filter + `
+			  AND ts >= ?
+			  AND ts < ?
+		)
+		WHERE ` + cond + `
+		ORDER BY ts
+		LIMIT 1
+	`
+
+	args := append([]any{}, src.Args...)
+	args = append(args, from, to)
+	args = append(args, condArgs...)
+
+	return scanPrint(services.GetClickHouse().QueryRow(query, args...))
+}
+
+// lastPrint returns the last print of src in [from, at].
+func lastPrint(src resampleSource, from, at time.Time) (time.Time, float64, bool, error) {
+	query := `
+		SELECT ts, ` + src.Price + ` AS price
+		FROM ` + src.Table + `
+		WHERE ` + src.Filter + `
+		  AND ts >= ?
+		  AND ts <= ?
+		ORDER BY ts DESC
+		LIMIT 1
+	`
+
+	args := append([]any{}, src.Args...)
+	args = append(args, from, at)
+
+	return scanPrint(services.GetClickHouse().QueryRow(query, args...))
+}
+
+func scanPrint(row *sql.Row) (time.Time, float64, bool, error) {
+	var ts time.Time
+	var price float64
+
+	err := row.Scan(&ts, &price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ts, price, false, nil
+	}
+
+	return ts, price, err == nil, err
+}
@@ -0,0 +1,208 @@
+package components
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// seriesOffset is how many futures expiries out a futures series sits.
+func seriesOffset(series string) int {
+	switch series {
+	case "next", "2":
+		return 1
+	case "far", "3":
+		return 2
+	}
+	return 0
+}
+
+// GetFuturesBasis joins a futures series with the spot index and returns
+// basis, basis percentage and annualised carry. Raw rows pair every
+// futures tick with the latest spot at or before it; resampled rows pair
+// the bucket closes of both.
+func GetFuturesBasis(
+	underlying string,
+	series string,
+	from time.Time,
+	to time.Time,
+	spec *ResampleSpec,
+) (models.FuturesBasisColumnar, error) {
+
+	out := models.FuturesBasisColumnar{
+		Ts:              []time.Time{},
+		Expiry:          []string{},
+		Futures:         []float64{},
+		Spot:            []float64{},
+		Basis:           []float64{},
+		BasisPct:        []float64{},
+		DaysToExpiry:    []float64{},
+		AnnualizedCarry: []float64{},
+	}
+
+	cal := calendar.For(underlying)
+
+	// the far contract of a bimonthly cycle can expire six months out
+	expiries, err := calendar.FuturesExpiries(underlying, from, to.AddDate(0, 7, 0))
+	if err != nil {
+		return out, err
+	}
+
+	// expiry close of each futures contract, in order
+	closes := make([]time.Time, 0, len(expiries))
+	for _, e := range expiries {
+		s, ok, _ := cal.Day(e)
+		if !ok {
+			continue
+		}
+		closes = append(closes, s.Close)
+	}
+
+	offset := seriesOffset(series)
+
+	add := func(ts time.Time, futures, spot float64) {
+		expiry := ""
+		dte := math.NaN()
+
+		// the near contract is the first one still trading at ts
+		i := sort.Search(len(closes), func(i int) bool { return closes[i].After(ts) })
+		if i+offset < len(closes) {
+			expiryClose := closes[i+offset]
+			expiry = expiryClose.Format("2006-01-02")
+			dte = expiryClose.Sub(ts).Hours() / 24
+		}
+
+		basis := futures - spot
+		basisPct := basis / spot * 100
+		carry := math.NaN()
+		if dte > 0 {
+			carry = (futures/spot - 1) * 365 / dte * 100
+		}
+
+		out.Ts = append(out.Ts, ts)
+		out.Expiry = append(out.Expiry, expiry)
+		out.Futures = append(out.Futures, futures)
+		out.Spot = append(out.Spot, spot)
+		out.Basis = append(out.Basis, basis)
+		out.BasisPct = append(out.BasisPct, basisPct)
+		out.DaysToExpiry = append(out.DaysToExpiry, dte)
+		out.AnnualizedCarry = append(out.AnnualizedCarry, carry)
+	}
+
+	if spec != nil {
+		futures, err := resampleOHLC(
+			resampleSource{
+				Table:  "second_data.futures_data",
+				Price:  "futures_price",
+				Filter: "underlying = ? AND series = ?",
+				Args:   []any{underlying, series},
+			},
+			cal, from, to, *spec,
+		)
+		if err != nil {
+			return out, err
+		}
+
+		spot, err := resampleOHLC(
+			resampleSource{
+				Table:  "second_data.index_data",
+				Price:  "spot_price",
+				Filter: "underlying = ?",
+				Args:   []any{underlying},
+			},
+			cal, from, to, *spec,
+		)
+		if err != nil {
+			return out, err
+		}
+
+		if futures.Filled != nil {
+			out.Filled = []bool{}
+		}
+		if futures.Partial != nil {
+			out.Partial = []bool{}
+		}
+
+		spotAt := make(map[int64]int, len(spot.Ts))
+		for i, ts := range spot.Ts {
+			spotAt[ts.Unix()] = i
+		}
+
+		for i, ts := range futures.Ts {
+			j, ok := spotAt[ts.Unix()]
+			if !ok {
+				continue
+			}
+			add(ts, futures.Close[i], spot.Close[j])
+
+			// a bucket is filled when either side carried its close in;
+			// both sides share the grid, so they agree on partial
+			if out.Filled != nil {
+				out.Filled = append(out.Filled, futures.Filled[i] || spot.Filled[j])
+			}
+			if out.Partial != nil {
+				out.Partial = append(out.Partial, futures.Partial[i])
+			}
+		}
+
+		return out, nil
+	}
+
+	// spot is looked up from the start of from's day, so the first
+	// futures ticks of the range still find a price
+	dayStart := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+	query := `
+		SELECT
+			f.ts,
+			f.futures_price,
+			i.spot_price
+		FROM second_data.futures_data AS f
+		ASOF INNER JOIN
+		(
+			SELECT underlying, ts, spot_price
+			FROM second_data.index_data
+			WHERE underlying = ?
+			  AND ts >= ?
+			  AND ts < ?
+		) AS i
+		ON f.underlying = i.underlying AND f.ts >= i.ts
+		WHERE f.underlying = ?
+		  AND f.series = ?
+		  AND f.ts >= ?
+		  AND f.ts < ?
+		ORDER BY f.ts
+	`
+
+	rows, err := services.GetClickHouse().Query(
+		query,
+		underlying,
+		dayStart,
+		to,
+		underlying,
+		series,
+		from,
+		to,
+	)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts time.Time
+		var futures, spot float64
+
+		if err := rows.Scan(&ts, &futures, &spot); err != nil {
+			return out, err
+		}
+
+		add(ts, futures, spot)
+	}
+
+	return out, rows.Err()
+}
@@ -3,6 +3,7 @@ package components
 import (
 	"time"
 
+	"quant-read-api/calendar"
 	"quant-read-api/models"
 	"quant-read-api/services"
 )
@@ -12,13 +13,12 @@ func GetFuturesData(
 	series string,
 	from time.Time,
 	to time.Time,
-	tfSeconds *int64,
-	offsetSeconds int64,
+	spec *ResampleSpec,
 ) (any, error) {
 
 	db := services.GetClickHouse()
 
-	if tfSeconds == nil {
+	if spec == nil {
 		query := `
 			SELECT
 				ts,
@@ -57,121 +57,16 @@ func GetFuturesData(
 		return out, nil
 	}
 
-	loc, _ := time.LoadLocation("Asia/Kolkata")
-
-	out := models.ColumnarOHLC{
-		Ts:    []time.Time{},
-		Open:  []float64{},
-		High:  []float64{},
-		Low:   []float64{},
-		Close: []float64{},
-	}
-
-	// Normalize to date boundaries
-	startDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
-	endDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
-
-	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
-
-		sessionStart := time.Date(
-			d.Year(), d.Month(), d.Day(),
-			9, 15, 0, 0,
-			loc,
-		)
-
-		sessionEnd := time.Date(
-			d.Year(), d.Month(), d.Day(),
-			15, 30, 0, 0,
-			loc,
-		)
-
-		// Clamp to user range
-		effectiveStart := sessionStart
-		if from.After(effectiveStart) {
-			effectiveStart = from
-		}
-
-		effectiveEnd := sessionEnd
-		if to.Before(effectiveEnd) {
-			effectiveEnd = to
-		}
-
-		if !effectiveStart.Before(effectiveEnd) {
-			continue
-		}
-
-		query := `
-		SELECT
-			bucket_ts AS ts,
-			argMin(futures_price, ts) AS open,
-			max(futures_price)        AS high,
-			min(futures_price)        AS low,
-			argMax(futures_price, ts) AS close
-		FROM
-		(
-			SELECT
-				ts,
-				futures_price,
-				?
-				+ ?
-				+ intDiv(
-					toUnixTimestamp(ts)
-					- toUnixTimestamp(?)
-					- ?,
-					?
-				) * ? AS bucket_ts
-			FROM second_data.futures_data
-			WHERE underlying = ?
-			  AND series = ?
-			  AND ts >= ?
-			  AND ts < ?
-		)
-		WHERE
-			bucket_ts >= ? + ?
-			AND bucket_ts + ? <= ?
-		GROUP BY bucket_ts
-		ORDER BY bucket_ts
-		`
-
-		rows, err := db.Query(
-			query,
-			sessionStart,
-			offsetSeconds,
-			sessionStart,
-			offsetSeconds,
-			*tfSeconds,
-			*tfSeconds,
-			underlying,
-			series,
-			effectiveStart,
-			effectiveEnd,
-			sessionStart,
-			*tfSeconds,
-			*tfSeconds,
-			effectiveEnd,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		for rows.Next() {
-			var ts time.Time
-			var o, h, l, c float64
-
-			if err := rows.Scan(&ts, &o, &h, &l, &c); err != nil {
-				rows.Close()
-				return nil, err
-			}
-
-			out.Ts = append(out.Ts, ts)
-			out.Open = append(out.Open, o)
-			out.High = append(out.High, h)
-			out.Low = append(out.Low, l)
-			out.Close = append(out.Close, c)
-		}
-
-		rows.Close()
-	}
-
-	return out, nil
+	return buildBars(
+		resampleSource{
+			Table:  "second_data.futures_data",
+			Price:  "futures_price",
+			Filter: "underlying = ? AND series = ?",
+			Args:   []any{underlying, series},
+		},
+		calendar.For(underlying),
+		from,
+		to,
+		*spec,
+	)
 }
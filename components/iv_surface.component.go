@@ -0,0 +1,218 @@
+package components
+
+import (
+	"math"
+	"time"
+
+	"quant-read-api/analytics"
+	"quant-read-api/models"
+)
+
+// MoneynessGrid is a uniform ln(strike / forward) grid that smiles are
+// interpolated onto, from Min to Max inclusive.
+type MoneynessGrid struct {
+	Min  float64
+	Max  float64
+	Step float64
+}
+
+// Points lists the grid values.
+func (g MoneynessGrid) Points() []float64 {
+	n := int(math.Floor((g.Max-g.Min)/g.Step+1e-9)) + 1
+
+	points := make([]float64, n)
+	for i := range points {
+		// rounded so 0.05 lands on 0.05, not 0.05000000000000002
+		points[i] = math.Round((g.Min+float64(i)*g.Step)*1e9) / 1e9
+	}
+	return points
+}
+
+// GetIVSurface builds the out-of-the-money smile of expiry, or of every
+// expiry listed on or after at when expiry is nil, from the chain as of
+// at. Each quote is solved against the spot printed on its own tick.
+func GetIVSurface(
+	underlying string,
+	expiry *time.Time,
+	at time.Time,
+	market analytics.Market,
+	grid *MoneynessGrid,
+) (models.IVSurface, error) {
+
+	out := models.IVSurface{Smiles: []models.IVSmile{}}
+
+	quotes, err := loadChainQuotes(underlying, expiry, at)
+	if err != nil {
+		return out, err
+	}
+	if len(quotes) == 0 {
+		return out, ErrNoQuotes
+	}
+
+	latest := freshest(quotes)
+	out.Spot = latest.Spot
+	out.SpotTs = latest.Ts
+
+	var points []float64
+	if grid != nil {
+		points = grid.Points()
+		out.Grid = points
+	}
+
+	solver := newIVSolver(underlying, market, false)
+
+	// quotes are ordered by expiry; cut them into one run per expiry
+	for start := 0; start < len(quotes); {
+		end := start
+		for end < len(quotes) && quotes[end].Expiry.Equal(quotes[start].Expiry) {
+			end++
+		}
+
+		smile := buildSmile(quotes[start:end], solver, at, out.Spot, market)
+		if points != nil {
+			smile.GridIV = interpolateSmile(smile, points)
+		}
+		out.Smiles = append(out.Smiles, smile)
+
+		start = end
+	}
+
+	return out, nil
+}
+
+// buildSmile solves one expiry's quotes, ordered by strike, keeping the
+// out-of-the-money side of every strike.
+func buildSmile(
+	quotes []chainQuote,
+	solver *ivSolver,
+	at time.Time,
+	spot float64,
+	market analytics.Market,
+) models.IVSmile {
+
+	expiry := quotes[0].Expiry
+	end := solver.closeOf(expiry)
+	t := analytics.YearFraction(end.Sub(at).Seconds())
+	forward := market.Forward(spot, t)
+
+	smile := models.IVSmile{
+		Expiry:       expiry.Format("2006-01-02"),
+		DaysToExpiry: end.Sub(at).Hours() / 24,
+		Forward:      forward,
+		AtmStrike:    freshest(quotes).AtmStrike,
+		Strike:       []uint32{},
+		OptionType:   []string{},
+		Moneyness:    []float64{},
+		IV:           []float64{},
+		IVStatus:     []string{},
+		Staleness:    []float64{},
+	}
+
+	solve := func(c chainQuote) optionPoint {
+		return solver.solve(c.Ts, c.Expiry, float64(c.Strike), c.OptionType, c.Ltp, c.Spot)
+	}
+
+	for i := 0; i < len(quotes); {
+		strike := quotes[i].Strike
+
+		var ce, pe *chainQuote
+		for ; i < len(quotes) && quotes[i].Strike == strike; i++ {
+			switch quotes[i].OptionType {
+			case "CE":
+				ce = &quotes[i]
+			case "PE":
+				pe = &quotes[i]
+			}
+		}
+
+		var side string
+		var p optionPoint
+		var quoted time.Time
+
+		switch {
+		case strike < smile.AtmStrike && pe != nil:
+			side, p, quoted = "PE", solve(*pe), pe.Ts
+		case strike > smile.AtmStrike && ce != nil:
+			side, p, quoted = "CE", solve(*ce), ce.Ts
+		case strike == smile.AtmStrike && (ce != nil || pe != nil):
+			side, p, quoted = atmPoint(ce, pe, solve)
+		default:
+			// only the in-the-money side traded
+			continue
+		}
+
+		smile.Strike = append(smile.Strike, strike)
+		smile.OptionType = append(smile.OptionType, side)
+		smile.Moneyness = append(smile.Moneyness, math.Log(float64(strike)/forward))
+		smile.IV = append(smile.IV, p.IV)
+		smile.IVStatus = append(smile.IVStatus, p.Status)
+		smile.Staleness = append(smile.Staleness, at.Sub(quoted).Seconds())
+	}
+
+	return smile
+}
+
+// atmPoint averages the CE and PE volatilities at the ATM strike, falling
+// back to whichever side solved.
+func atmPoint(ce, pe *chainQuote, solve func(chainQuote) optionPoint) (string, optionPoint, time.Time) {
+	if ce == nil {
+		return "PE", solve(*pe), pe.Ts
+	}
+	if pe == nil {
+		return "CE", solve(*ce), ce.Ts
+	}
+
+	c, p := solve(*ce), solve(*pe)
+
+	// staleness of the older leg
+	quoted := ce.Ts
+	if pe.Ts.Before(quoted) {
+		quoted = pe.Ts
+	}
+
+	switch {
+	case c.Status == analytics.StatusOK && p.Status == analytics.StatusOK:
+		c.IV = (c.IV + p.IV) / 2
+		return "CE+PE", c, quoted
+	case p.Status == analytics.StatusOK:
+		return "PE", p, pe.Ts
+	default:
+		return "CE", c, ce.Ts
+	}
+}
+
+// interpolateSmile linearly interpolates the solved points of smile onto
+// points. Grid values outside the quoted moneyness range are null; the
+// smile is never extrapolated.
+func interpolateSmile(smile models.IVSmile, points []float64) []float64 {
+	xs := []float64{}
+	ys := []float64{}
+	for i, status := range smile.IVStatus {
+		if status == analytics.StatusOK {
+			xs = append(xs, smile.Moneyness[i])
+			ys = append(ys, smile.IV[i])
+		}
+	}
+
+	out := make([]float64, len(points))
+
+	j := 0
+	for i, x := range points {
+		out[i] = math.NaN()
+
+		for j+1 < len(xs) && xs[j+1] < x {
+			j++
+		}
+
+		switch {
+		case len(xs) == 0 || x < xs[0] || x > xs[len(xs)-1]:
+		case x == xs[j]:
+			out[i] = ys[j]
+		case j+1 < len(xs):
+			w := (x - xs[j]) / (xs[j+1] - xs[j])
+			out[i] = ys[j] + w*(ys[j+1]-ys[j])
+		}
+	}
+
+	return out
+}
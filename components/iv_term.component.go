@@ -0,0 +1,506 @@
+package components
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"quant-read-api/analytics"
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// minIndexDays keeps expiries this close to their close out of the
+// volatility index: expiry-day ATM premiums are mostly noise.
+const minIndexDays = 1.0
+
+// atmQuote is the last ATM CE and PE print of one expiry in a bucket.
+type atmQuote struct {
+	Expiry time.Time
+
+	CeLtp, CeSpot float64
+	CeStrike      uint32
+	CeTs          time.Time
+
+	PeLtp, PeSpot float64
+	PeStrike      uint32
+	PeTs          time.Time
+}
+
+// merge takes the sides newer printed, keeping the rest of q.
+func (q atmQuote) merge(newer atmQuote) atmQuote {
+	q.Expiry = newer.Expiry
+	if newer.CeTs.Unix() > 0 {
+		q.CeLtp, q.CeSpot, q.CeStrike, q.CeTs = newer.CeLtp, newer.CeSpot, newer.CeStrike, newer.CeTs
+	}
+	if newer.PeTs.Unix() > 0 {
+		q.PeLtp, q.PeSpot, q.PeStrike, q.PeTs = newer.PeLtp, newer.PeSpot, newer.PeStrike, newer.PeTs
+	}
+	return q
+}
+
+// since drops the sides printed before open, so a quote is never carried
+// out of its session.
+func (q atmQuote) since(open time.Time) atmQuote {
+	if q.CeTs.Before(open) {
+		q.CeTs = time.Time{}
+	}
+	if q.PeTs.Before(open) {
+		q.PeTs = time.Time{}
+	}
+	return q
+}
+
+// termPoint is one expiry's ATM volatility at one ts.
+type termPoint struct {
+	Expiry    string
+	Days      float64
+	AtmStrike uint32
+	IV        float64
+	Status    string
+}
+
+// termRow is one ts of the term structure.
+type termRow struct {
+	Index  float64
+	Points []termPoint // by rank, nearest first
+}
+
+// GetIVTermStructure returns the ATM implied volatility of the nearest
+// ranks expiries and a tenorDays constant-maturity volatility index, per
+// second or resampled like candles.
+//
+// The index follows the VIX recipe on ATM volatility instead of a full
+// strike strip: total variance σ²T of the two expiries bracketing the
+// tenor is interpolated linearly in time, then annualised and quoted in
+// volatility points. Outside the listed expiries it holds the nearest
+// volatility flat. The index reads every listed expiry up to the one
+// bracketing the tenor, however few ranks are displayed.
+//
+// Ranks follow the listed expiries by date, not the ones that printed in a
+// bucket: each expiry's last ATM quote in the session is carried forward,
+// and an expiry with none yet holds its rank with a no_price status.
+func GetIVTermStructure(
+	underlying string,
+	from time.Time,
+	to time.Time,
+	spec *ResampleSpec,
+	market analytics.Market,
+	ranks int,
+	tenorDays int,
+) (models.IVTermStructure, error) {
+
+	out := models.IVTermStructure{
+		Ts:    []time.Time{},
+		Index: []float64{},
+		Terms: make([]models.IVTerm, ranks),
+	}
+	for r := range out.Terms {
+		out.Terms[r] = models.IVTerm{
+			Rank:         r + 1,
+			Expiry:       []string{},
+			DaysToExpiry: []float64{},
+			AtmStrike:    []uint32{},
+			IV:           []float64{},
+			IVStatus:     []string{},
+		}
+	}
+
+	cal := calendar.For(underlying)
+
+	listed, err := listedExpiries(underlying, from, to)
+	if err != nil {
+		return out, err
+	}
+
+	expiries := make([]string, len(listed))
+	for i, e := range listed {
+		expiries[i] = e.Expiry.Format("2006-01-02")
+	}
+
+	// every expiry that is among the nearest ranks somewhere in the range,
+	// one more in case the expiry on to's day closes before to, and
+	// through the first expiry a full tenor past to, which brackets the
+	// index at every ts
+	toDay := to.Format("2006-01-02")
+	last := sort.SearchStrings(expiries, toDay)
+	bracket := sort.SearchStrings(expiries, to.AddDate(0, 0, tenorDays).Format("2006-01-02"))
+	expiries = expiries[:min(max(last+ranks+1, bracket+1), len(expiries))]
+	listed = listed[:len(expiries)]
+	if len(expiries) == 0 {
+		return out, nil
+	}
+
+	quoteSelect := `
+		argMaxIf(ltp, tick_ts, option_type = 'CE')        AS ce_ltp,
+		argMaxIf(spot_price, tick_ts, option_type = 'CE') AS ce_spot,
+		argMaxIf(strike, tick_ts, option_type = 'CE')     AS ce_strike,
+		maxIf(tick_ts, option_type = 'CE')                AS ce_ts,
+		argMaxIf(ltp, tick_ts, option_type = 'PE')        AS pe_ltp,
+		argMaxIf(spot_price, tick_ts, option_type = 'PE') AS pe_spot,
+		argMaxIf(strike, tick_ts, option_type = 'PE')     AS pe_strike,
+		maxIf(tick_ts, option_type = 'PE')                AS pe_ts`
+
+	atmFilter := `underlying = ?
+		  AND has(?, toString(expiry))
+		  AND moneyness = 'ATM'
+		  AND moneyness_lvl = 0`
+
+	var query string
+	var args []any
+	var b bucketing
+
+	if spec == nil {
+		query = `
+		SELECT
+			toInt64(toUnixTimestamp(ts)) AS bucket_ts,
+			expiry,` + quoteSelect + `
+		FROM
+		(
+			SELECT *, ts AS tick_ts
+			FROM options_moneyness
+			WHERE ` + atmFilter + `
+			  AND ts >= ?
+			  AND ts < ?
+		)
+		GROUP BY bucket_ts, expiry
+		ORDER BY bucket_ts, expiry
+		`
+		args = []any{underlying, expiries, from, to}
+	} else {
+		var ok bool
+		b, ok = newBucketing(cal, from, to, *spec)
+		if !ok {
+			return out, nil
+		}
+
+		query = b.With + `
+		SELECT
+			bucket_ts,
+			expiry,` + quoteSelect + `
+		FROM
+		(
+			SELECT
+				ts AS tick_ts,
+				expiry,
+				strike,
+				option_type,
+				ltp,
+				spot_price,
+				` + b.Columns + `
+			FROM options_moneyness
+			WHERE ` + atmFilter + `
+			  AND ` + b.Where + `
+		)
+		GROUP BY bucket_ts, expiry
+		ORDER BY bucket_ts, expiry
+		`
+		args = append([]any{}, b.Args...)
+		args = append(args, underlying, expiries)
+		args = append(args, b.RangeArgs()...)
+	}
+
+	db := services.GetClickHouse()
+
+	// last quote of every expiry from the open of from's session, carried
+	// into the first bucket
+	carried := map[string]atmQuote{}
+
+	if s, ok := sessionAsOf(cal, from); ok && s.Open.Before(from) {
+		seed := `
+		SELECT
+			expiry,` + quoteSelect + `
+		FROM
+		(
+			SELECT *, ts AS tick_ts
+			FROM options_moneyness
+			WHERE ` + atmFilter + `
+			  AND ts >= ?
+			  AND ts < ?
+		)
+		GROUP BY expiry
+		`
+
+		rows, err := db.Query(seed, underlying, expiries, s.Open, from)
+		if err != nil {
+			return out, err
+		}
+
+		for rows.Next() {
+			var q atmQuote
+			if err := rows.Scan(
+				&q.Expiry,
+				&q.CeLtp,
+				&q.CeSpot,
+				&q.CeStrike,
+				&q.CeTs,
+				&q.PeLtp,
+				&q.PeSpot,
+				&q.PeStrike,
+				&q.PeTs,
+			); err != nil {
+				rows.Close()
+				return out, err
+			}
+			carried[q.Expiry.Format("2006-01-02")] = q
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return out, err
+		}
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	buckets := []int64{}
+	quotes := map[int64][]atmQuote{}
+
+	for rows.Next() {
+		var bucket int64
+		var q atmQuote
+
+		if err := rows.Scan(
+			&bucket,
+			&q.Expiry,
+			&q.CeLtp,
+			&q.CeSpot,
+			&q.CeStrike,
+			&q.CeTs,
+			&q.PeLtp,
+			&q.PeSpot,
+			&q.PeStrike,
+			&q.PeTs,
+		); err != nil {
+			return out, err
+		}
+
+		if _, seen := quotes[bucket]; !seen {
+			buckets = append(buckets, bucket)
+		}
+		quotes[bucket] = append(quotes[bucket], q)
+	}
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+
+	solver := newIVSolver(underlying, market, false)
+	tenor := float64(tenorDays)
+
+	var session calendar.Session
+
+	// rowAt carries a bucket's prints into the book and ranks every listed
+	// expiry as of the bucket's latest print
+	rowAt := func(bucket []atmQuote) termRow {
+		var at time.Time
+		for _, q := range bucket {
+			key := q.Expiry.Format("2006-01-02")
+			carried[key] = carried[key].merge(q)
+
+			if q.CeTs.After(at) {
+				at = q.CeTs
+			}
+			if q.PeTs.After(at) {
+				at = q.PeTs
+			}
+		}
+
+		if at.Before(session.Open) || !at.Before(session.Close) {
+			session, _ = sessionAsOf(cal, at)
+		}
+
+		return termRowOf(at, session.Open, listed, carried, solver, ranks, tenor)
+	}
+
+	loc := cal.Location()
+
+	appendRow := func(ts int64, row termRow) {
+		out.Ts = append(out.Ts, time.Unix(ts, 0).In(loc))
+		out.Index = append(out.Index, row.Index)
+
+		for r := range out.Terms {
+			t := &out.Terms[r]
+
+			p := termPoint{Days: math.NaN(), IV: math.NaN()}
+			if r < len(row.Points) {
+				p = row.Points[r]
+			}
+
+			t.Expiry = append(t.Expiry, p.Expiry)
+			t.DaysToExpiry = append(t.DaysToExpiry, p.Days)
+			t.AtmStrike = append(t.AtmStrike, p.AtmStrike)
+			t.IV = append(t.IV, p.IV)
+			t.IVStatus = append(t.IVStatus, p.Status)
+		}
+	}
+
+	if spec == nil {
+		for _, bucket := range buckets {
+			appendRow(bucket, rowAt(quotes[bucket]))
+		}
+		return out, nil
+	}
+
+	fill := spec.Fill
+	if fill == "" {
+		fill = FillNone
+	}
+	if fill != FillNone {
+		out.Filled = []bool{}
+	}
+	if spec.Partial == PartialFlag {
+		out.Partial = []bool{}
+	}
+
+	var prev *termRow
+
+	for _, g := range bucketGrid(b.Windows, b.Periods, *spec) {
+		if g.dropped(spec.Partial) {
+			continue
+		}
+
+		q, ok := quotes[g.Start]
+
+		var row termRow
+		switch {
+		case ok:
+			row = rowAt(q)
+			prev = &row
+		case fill == FillForward && prev != nil:
+			row = *prev
+		case fill == FillNull:
+			row = termRow{Index: math.NaN()}
+		default:
+			continue
+		}
+
+		label := g.Start
+		if spec.Label == LabelEnd {
+			label = g.End
+		}
+
+		appendRow(label, row)
+
+		if out.Filled != nil {
+			out.Filled = append(out.Filled, !ok)
+		}
+		if out.Partial != nil {
+			out.Partial = append(out.Partial, g.Partial)
+		}
+	}
+
+	return out, nil
+}
+
+// termRowOf solves the ATM volatility of every expiry listed by open's
+// session and still trading at at, from the quotes carried since open,
+// interpolates the index from all of them and keeps the nearest ranks as
+// points. expiries are in date order.
+func termRowOf(
+	at time.Time,
+	open time.Time,
+	expiries []listedExpiry,
+	carried map[string]atmQuote,
+	solver *ivSolver,
+	ranks int,
+	tenor float64,
+) termRow {
+
+	points := []termPoint{}
+
+	for _, l := range expiries {
+		e := l.Expiry
+
+		end := solver.closeOf(e)
+		if !end.After(at) || !l.listedBy(open) {
+			continue
+		}
+
+		key := e.Format("2006-01-02")
+
+		q := carried[key].since(open)
+		q.Expiry = e
+
+		p := atmPointOf(q, solver)
+		p.Expiry = key
+		p.Days = end.Sub(at).Hours() / 24
+
+		points = append(points, p)
+	}
+
+	return termRow{
+		Index:  volIndex(points, tenor),
+		Points: points[:min(ranks, len(points))],
+	}
+}
+
+// atmPointOf averages the CE and PE volatilities of an expiry's ATM
+// strike. When the ATM strike moved between the two prints, or only one
+// side solved, the fresher solved side is used alone.
+func atmPointOf(q atmQuote, solver *ivSolver) termPoint {
+	ce := optionPoint{IV: math.NaN(), Status: analytics.StatusNoPrice}
+	pe := optionPoint{IV: math.NaN(), Status: analytics.StatusNoPrice}
+
+	if q.CeTs.Unix() > 0 {
+		ce = solver.solve(q.CeTs, q.Expiry, float64(q.CeStrike), "CE", q.CeLtp, q.CeSpot)
+	}
+	if q.PeTs.Unix() > 0 {
+		pe = solver.solve(q.PeTs, q.Expiry, float64(q.PeStrike), "PE", q.PeLtp, q.PeSpot)
+	}
+
+	ceOK := ce.Status == analytics.StatusOK
+	peOK := pe.Status == analytics.StatusOK
+
+	switch {
+	case ceOK && peOK && q.CeStrike == q.PeStrike:
+		return termPoint{AtmStrike: q.CeStrike, IV: (ce.IV + pe.IV) / 2, Status: analytics.StatusOK}
+	case ceOK && (!peOK || !q.CeTs.Before(q.PeTs)):
+		return termPoint{AtmStrike: q.CeStrike, IV: ce.IV, Status: ce.Status}
+	case peOK:
+		return termPoint{AtmStrike: q.PeStrike, IV: pe.IV, Status: pe.Status}
+	case q.CeTs.Unix() > 0:
+		return termPoint{AtmStrike: q.CeStrike, IV: math.NaN(), Status: ce.Status}
+	default:
+		return termPoint{AtmStrike: q.PeStrike, IV: math.NaN(), Status: pe.Status}
+	}
+}
+
+// volIndex interpolates total variance to tenor days and returns the
+// annualised volatility in points (15.2 for 15.2%), NaN with nothing to
+// interpolate from.
+func volIndex(points []termPoint, tenor float64) float64 {
+	type term struct{ days, variance float64 }
+
+	terms := []term{}
+	for _, p := range points {
+		if p.Status == analytics.StatusOK && p.Days >= minIndexDays {
+			terms = append(terms, term{p.Days, p.IV * p.IV * p.Days})
+		}
+	}
+
+	switch {
+	case len(terms) == 0:
+		return math.NaN()
+	case tenor <= terms[0].days:
+		return 100 * math.Sqrt(terms[0].variance/terms[0].days)
+	case tenor >= terms[len(terms)-1].days:
+		last := terms[len(terms)-1]
+		return 100 * math.Sqrt(last.variance/last.days)
+	}
+
+	i := 1
+	for terms[i].days < tenor {
+		i++
+	}
+	near, next := terms[i-1], terms[i]
+
+	w := (tenor - near.days) / (next.days - near.days)
+	variance := near.variance + w*(next.variance-near.variance)
+
+	return 100 * math.Sqrt(variance/tenor)
+}
@@ -3,6 +3,8 @@ package components
 import (
 	"time"
 
+	"quant-read-api/analytics"
+	"quant-read-api/calendar"
 	"quant-read-api/models"
 	"quant-read-api/services"
 )
@@ -12,10 +14,12 @@ func GetOptionContractsByPremium(
 	optionType string, // CE | PE | BOTH
 	targetPremium float64,
 	tolerance float64,
-	expiryMode string,
+	expiryRule ExpiryRule,
 	from time.Time,
 	to time.Time,
 	limit int,
+	market *analytics.Market,
+	greeks bool,
 ) ([]models.OptionSnapshot, error) {
 
 	db := services.GetClickHouse()
@@ -30,33 +34,9 @@ func GetOptionContractsByPremium(
 	}
 
 	// expiry filter
-	var expirySQL string
-	var expiryArgs []any
-
-	if expiryMode != "all" {
-		if optionType == "BOTH" {
-			expirySQL = `
-				AND expiry = (
-					SELECT min(expiry)
-					FROM options_moneyness
-					WHERE underlying = ?
-					  AND option_type IN ('CE','PE')
-					  AND expiry >= toDate(?)
-				)
-			`
-			expiryArgs = append(expiryArgs, underlying, from.Format("2006-01-02"))
-		} else {
-			expirySQL = `
-				AND expiry = (
-					SELECT min(expiry)
-					FROM options_moneyness
-					WHERE underlying = ?
-					  AND option_type = ?
-					  AND expiry >= toDate(?)
-				)
-			`
-			expiryArgs = append(expiryArgs, underlying, optionType, from.Format("2006-01-02"))
-		}
+	expirySQL, expiryArgs, err := expiryFilter(underlying, calendar.For(underlying), from, to, expiryRule)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
@@ -77,7 +57,7 @@ func GetOptionContractsByPremium(
 		  AND ` + optionFilter + `
 		  AND ts BETWEEN ? AND ?
 		  AND ltp BETWEEN ? AND ?
-		  ` + expirySQL + `
+		  AND ` + expirySQL + `
 		ORDER BY abs(moneyness_lvl) ASC
 		LIMIT ?
 	`
@@ -119,6 +99,13 @@ func GetOptionContractsByPremium(
 		}
 		out = append(out, r)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if market != nil {
+		snapshotAnalytics(out, *market, greeks)
+	}
 
 	return out, nil
 }
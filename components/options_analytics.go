@@ -0,0 +1,207 @@
+package components
+
+import (
+	"math"
+	"time"
+
+	"quant-read-api/analytics"
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+)
+
+// expiryClose is the close of the expiry day's session, when an option's
+// time value runs out. Expiries on a closed day fall back to the last
+// session before it.
+func expiryClose(cal *calendar.Calendar, expiry time.Time) time.Time {
+	day := time.Date(expiry.Year(), expiry.Month(), expiry.Day(), 0, 0, 0, 0, cal.Location())
+
+	for i := 0; i < 10; i++ {
+		if s, ok, _ := cal.Day(day); ok {
+			return s.Close
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return day
+}
+
+// ivSolver prices option rows of one underlying, caching expiry closes.
+type ivSolver struct {
+	cal    *calendar.Calendar
+	market analytics.Market
+	greeks bool
+	closes map[string]time.Time
+}
+
+func newIVSolver(underlying string, market analytics.Market, greeks bool) *ivSolver {
+	return &ivSolver{
+		cal:    calendar.For(underlying),
+		market: market,
+		greeks: greeks,
+		closes: map[string]time.Time{},
+	}
+}
+
+// closeOf is expiryClose, cached per expiry.
+func (s *ivSolver) closeOf(expiry time.Time) time.Time {
+	key := expiry.Format("2006-01-02")
+
+	end, ok := s.closes[key]
+	if !ok {
+		end = expiryClose(s.cal, expiry)
+		s.closes[key] = end
+	}
+
+	return end
+}
+
+// optionPoint is the IV and greeks of one premium, NaN when unsolvable.
+type optionPoint struct {
+	IV                        float64
+	Status                    string
+	Delta, Gamma, Vega, Theta float64
+}
+
+// solve returns the implied volatility of premium at ts, using the time
+// left to the expiry close to the second, and the greeks at that
+// volatility when the solver was built for them.
+func (s *ivSolver) solve(
+	ts time.Time,
+	expiry time.Time,
+	strike float64,
+	optionType string,
+	premium float64,
+	spot float64,
+) optionPoint {
+
+	t := analytics.YearFraction(s.closeOf(expiry).Sub(ts).Seconds())
+	call := optionType == "CE"
+
+	var p optionPoint
+	p.IV, p.Status = analytics.ImpliedVol(premium, spot, strike, t, call, s.market)
+
+	if s.greeks {
+		g := analytics.BlackScholesGreeks(spot, strike, t, p.IV, call, s.market)
+		p.Delta, p.Gamma, p.Vega, p.Theta = g.Delta, g.Gamma, g.Vega, g.Theta
+	}
+
+	return p
+}
+
+// row converts p for row responses, leaving out what was not solved or
+// not asked for.
+func (s *ivSolver) row(p optionPoint) models.OptionAnalytics {
+	a := models.OptionAnalytics{
+		IV:       optionalFloat(p.IV),
+		IVStatus: p.Status,
+	}
+
+	if s.greeks {
+		a.Delta = optionalFloat(p.Delta)
+		a.Gamma = optionalFloat(p.Gamma)
+		a.Vega = optionalFloat(p.Vega)
+		a.Theta = optionalFloat(p.Theta)
+	}
+
+	return a
+}
+
+// optionalFloat maps NaN to nil, for row fields that are omitted when
+// there is no value.
+func optionalFloat(v float64) *float64 {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
+
+// snapshotAnalytics solves IV, and greeks when asked, on every row.
+func snapshotAnalytics(rows []models.OptionSnapshot, market analytics.Market, greeks bool) {
+	solvers := map[string]*ivSolver{}
+
+	for i := range rows {
+		r := &rows[i]
+
+		solver, ok := solvers[r.Underlying]
+		if !ok {
+			solver = newIVSolver(r.Underlying, market, greeks)
+			solvers[r.Underlying] = solver
+		}
+
+		p := solver.solve(r.Ts, r.Expiry, float64(r.Strike), r.OptionType, r.Ltp, r.SpotPrice)
+		r.OptionAnalytics = solver.row(p)
+	}
+}
+
+// contractAnalytics adds IV, and greeks when asked, to option candles.
+// Each bucket is solved at its close: the last premium against the spot
+// printed on that same tick, at that tick's time, and the expiry
+// expiryOn resolved for that tick's day.
+func contractAnalytics(
+	c *models.ColumnarOHLC,
+	src resampleSource,
+	cal *calendar.Calendar,
+	from time.Time,
+	to time.Time,
+	spec ResampleSpec,
+	underlying string,
+	expiryOn map[string]time.Time,
+	strike uint32,
+	optionType string,
+	market analytics.Market,
+	greeks bool,
+) error {
+
+	// the same ticks bucketed on their spot column land on the same grid
+	spotSpec := spec
+	spotSpec.Aggs = []string{AggLastTs}
+
+	spotSrc := src
+	spotSrc.Price = "spot_price"
+
+	spot, err := resampleOHLC(spotSrc, cal, from, to, spotSpec)
+	if err != nil {
+		return err
+	}
+
+	solver := newIVSolver(underlying, market, greeks)
+
+	n := len(c.Ts)
+	c.IV = make([]float64, n)
+	c.IVStatus = make([]string, n)
+	if greeks {
+		c.Delta = make([]float64, n)
+		c.Gamma = make([]float64, n)
+		c.Vega = make([]float64, n)
+		c.Theta = make([]float64, n)
+	}
+
+	var expiry time.Time
+
+	for i := range c.Ts {
+		// filled buckets have no tick of their own; use the bucket time
+		at := c.Ts[i]
+		if i < len(spot.LastTs) && !spot.LastTs[i].IsZero() {
+			at = spot.LastTs[i]
+		}
+
+		// the expiry of the day the bucket closed on, else the last one
+		if e, ok := expiryOn[at.In(cal.Location()).Format("2006-01-02")]; ok {
+			expiry = e
+		}
+
+		spotClose := math.NaN()
+		if i < len(spot.Close) {
+			spotClose = spot.Close[i]
+		}
+
+		p := solver.solve(at, expiry, float64(strike), optionType, c.Close[i], spotClose)
+
+		c.IV[i], c.IVStatus[i] = p.IV, p.Status
+		if greeks {
+			c.Delta[i], c.Gamma[i], c.Vega[i], c.Theta[i] = p.Delta, p.Gamma, p.Vega, p.Theta
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package components
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// sessionAsOf returns the latest session that opened at or before at.
+// Chain quotes are only looked up inside it, so a strike that has not
+// traded today is missing rather than carrying yesterday's print.
+func sessionAsOf(cal *calendar.Calendar, at time.Time) (calendar.Session, bool) {
+	day := at.In(cal.Location())
+
+	for i := 0; i < 10; i++ {
+		if s, ok, _ := cal.Day(day); ok && !s.Open.After(at) {
+			return s, true
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return calendar.Session{}, false
+}
+
+// ErrNoQuotes is returned by the point-in-time chain readers when no
+// contract of the expiry has printed in the session at falls in.
+var ErrNoQuotes = errors.New("no quotes for the expiry in the session of at")
+
+// chainQuote is the latest print of one contract as of a point in time.
+type chainQuote struct {
+	Expiry     time.Time
+	Strike     uint32
+	OptionType string
+	Ltp        float64
+	Ts         time.Time
+	Spot       float64 // spot on the same tick
+	AtmStrike  uint32
+}
+
+// loadChainQuotes returns the latest print of every contract at or before
+// at, within the session at falls in, ordered by expiry, strike and
+// option type. A nil expiry loads every expiry listed on or after at.
+func loadChainQuotes(underlying string, expiry *time.Time, at time.Time) ([]chainQuote, error) {
+	out := []chainQuote{}
+
+	session, ok := sessionAsOf(calendar.For(underlying), at)
+	if !ok {
+		return out, nil
+	}
+
+	expirySQL := "AND expiry >= toDate(?)"
+	expiryArg := at.Format("2006-01-02")
+	if expiry != nil {
+		expirySQL = "AND expiry = toDate(?)"
+		expiryArg = expiry.Format("2006-01-02")
+	}
+
+	query := `
+		SELECT
+			expiry,
+			strike,
+			option_type,
+			argMax(ltp, ts)        AS last_ltp,
+			max(ts)                AS last_ts,
+			argMax(spot_price, ts) AS last_spot,
+			argMax(atm_strike, ts) AS last_atm
+		FROM options_moneyness
+		WHERE underlying = ?
+		  ` + expirySQL + `
+		  AND ts >= ?
+		  AND ts <= ?
+		GROUP BY expiry, strike, option_type
+		ORDER BY expiry, strike, option_type
+	`
+
+	rows, err := services.GetClickHouse().Query(
+		query,
+		underlying,
+		expiryArg,
+		session.Open,
+		at,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c chainQuote
+		if err := rows.Scan(
+			&c.Expiry,
+			&c.Strike,
+			&c.OptionType,
+			&c.Ltp,
+			&c.Ts,
+			&c.Spot,
+			&c.AtmStrike,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+
+	return out, rows.Err()
+}
+
+// freshest returns the most recent quote, which spot and ATM are read from.
+func freshest(quotes []chainQuote) chainQuote {
+	var latest chainQuote
+	for _, c := range quotes {
+		if c.Ts.After(latest.Ts) {
+			latest = c
+		}
+	}
+	return latest
+}
+
+// GetOptionChain returns the latest CE and PE ltp of every strike of
+// expiry at or before at, within the session at falls in.
+func GetOptionChain(
+	underlying string,
+	expiry time.Time,
+	at time.Time,
+) (models.OptionChain, error) {
+
+	out := models.OptionChain{
+		Strike:      []uint32{},
+		CeLtp:       []float64{},
+		CeTs:        []time.Time{},
+		CeStaleness: []float64{},
+		PeLtp:       []float64{},
+		PeTs:        []time.Time{},
+		PeStaleness: []float64{},
+	}
+
+	quotes, err := loadChainQuotes(underlying, &expiry, at)
+	if err != nil {
+		return out, err
+	}
+	if len(quotes) == 0 {
+		return out, ErrNoQuotes
+	}
+
+	latest := freshest(quotes)
+	out.Spot = latest.Spot
+	out.SpotTs = latest.Ts
+	out.AtmStrike = latest.AtmStrike
+
+	nan := math.NaN()
+
+	for _, c := range quotes {
+		n := len(out.Strike)
+		if n == 0 || out.Strike[n-1] != c.Strike {
+			out.Strike = append(out.Strike, c.Strike)
+			out.CeLtp = append(out.CeLtp, nan)
+			out.CeTs = append(out.CeTs, time.Time{})
+			out.CeStaleness = append(out.CeStaleness, nan)
+			out.PeLtp = append(out.PeLtp, nan)
+			out.PeTs = append(out.PeTs, time.Time{})
+			out.PeStaleness = append(out.PeStaleness, nan)
+			n++
+		}
+
+		staleness := at.Sub(c.Ts).Seconds()
+
+		switch c.OptionType {
+		case "CE":
+			out.CeLtp[n-1] = c.Ltp
+			out.CeTs[n-1] = c.Ts
+			out.CeStaleness[n-1] = staleness
+		case "PE":
+			out.PeLtp[n-1] = c.Ltp
+			out.PeTs[n-1] = c.Ts
+			out.PeStaleness[n-1] = staleness
+		}
+	}
+
+	return out, nil
+}
@@ -3,28 +3,36 @@ package components
 import (
 	"time"
 
+	"quant-read-api/analytics"
+	"quant-read-api/calendar"
 	"quant-read-api/models"
 	"quant-read-api/services"
 )
 
 func GetOptionContract(
 	underlying string,
-	expiry time.Time,
+	expiryRule ExpiryRule,
 	strike uint32,
 	optionType string,
 	from time.Time,
 	to time.Time,
-	tfSeconds *int64,
-	offsetSeconds int64,
+	spec *ResampleSpec,
+	market *analytics.Market,
+	greeks bool,
 ) (any, error) {
 
 	db := services.GetClickHouse()
-	loc, _ := time.LoadLocation("Asia/Kolkata")
+	cal := calendar.For(underlying)
+
+	expirySQL, expiryArgs, err := expiryFilter(underlying, cal, from, to, expiryRule)
+	if err != nil {
+		return nil, err
+	}
 
 	// =========================
 	// RAW PATH (MULTI-DAY SAFE)
 	// =========================
-	if tfSeconds == nil {
+	if spec == nil {
 		query := `
 			SELECT
 				ts,
@@ -40,7 +48,7 @@ func GetOptionContract(
 				days_to_expiry
 			FROM options_moneyness
 			WHERE underlying = ?
-			  AND expiry = toDate(?)
+			  AND ` + expirySQL + `
 			  AND strike = ?
 			  AND option_type = ?
 			  AND ts >= ?
@@ -48,15 +56,10 @@ func GetOptionContract(
 			ORDER BY ts
 		`
 
-		rows, err := db.Query(
-			query,
-			underlying,
-			expiry,
-			strike,
-			optionType,
-			from,
-			to,
-		)
+		args := append([]any{underlying}, expiryArgs...)
+		args = append(args, strike, optionType, from, to)
+
+		rows, err := db.Query(query, args...)
 		if err != nil {
 			return nil, err
 		}
@@ -83,6 +86,18 @@ func GetOptionContract(
 			}
 			out = append(out, r)
 		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		if market != nil {
+			solver := newIVSolver(underlying, *market, greeks)
+			for i := range out {
+				r := &out[i]
+				p := solver.solve(r.Ts, r.Expiry, float64(r.Strike), r.OptionType, r.Ltp, r.SpotPrice)
+				r.OptionAnalytics = solver.row(p)
+			}
+		}
 
 		return out, nil
 	}
@@ -91,144 +106,39 @@ func GetOptionContract(
 	// RESAMPLED PATH (MULTI-DAY, OFFSET OK)
 	// =====================================
 
-	type candle struct {
-		Ts    time.Time
-		Open  float64
-		High  float64
-		Low   float64
-		Close float64
+	src := resampleSource{
+		Table: "options_moneyness",
+		Price: "ltp",
+		Filter: `underlying = ?
+			  AND ` + expirySQL + `
+			  AND strike = ?
+			  AND option_type = ?`,
+		Args: append(append([]any{underlying}, expiryArgs...), strike, optionType),
 	}
 
-	all := []candle{}
-
-	day := time.Date(
-		from.In(loc).Year(),
-		from.In(loc).Month(),
-		from.In(loc).Day(),
-		0, 0, 0, 0, loc,
-	)
-
-	lastDay := time.Date(
-		to.In(loc).Year(),
-		to.In(loc).Month(),
-		to.In(loc).Day(),
-		0, 0, 0, 0, loc,
-	)
-
-	for !day.After(lastDay) {
-
-		sessionStart := time.Date(
-			day.Year(), day.Month(), day.Day(),
-			9, 15, 0, 0, loc,
-		)
-
-		sessionEnd := time.Date(
-			day.Year(), day.Month(), day.Day(),
-			15, 30, 0, 0, loc,
-		)
-
-		effectiveStart := sessionStart
-		if from.After(effectiveStart) {
-			effectiveStart = from
-		}
-
-		effectiveEnd := sessionEnd
-		if to.Before(effectiveEnd) {
-			effectiveEnd = to
-		}
-
-		if !effectiveStart.Before(effectiveEnd) {
-			day = day.AddDate(0, 0, 1)
-			continue
-		}
-
-		query := `
-		SELECT
-			bucket_ts AS ts,
-			argMin(ltp, ts) AS open,
-			max(ltp)        AS high,
-			min(ltp)        AS low,
-			argMax(ltp, ts) AS close
-		FROM
-		(
-			SELECT
-				ts,
-				ltp,
-				(?)
-				+ ?
-				+ intDiv(
-					toUnixTimestamp(ts)
-					- toUnixTimestamp(?)
-					- ?,
-					?
-				) * ? AS bucket_ts
-			FROM options_moneyness
-			WHERE
-				underlying = ?
-				AND expiry = toDate(?)
-				AND strike = ?
-				AND option_type = ?
-				AND ts >= ?
-				AND ts < ?
-		)
-		WHERE
-			bucket_ts >= ? + ?
-			AND bucket_ts + ? <= ?
-		GROUP BY bucket_ts
-		ORDER BY bucket_ts
-		`
-
-		rows, err := db.Query(
-			query,
-			sessionStart,
-			offsetSeconds,
-			sessionStart,
-			offsetSeconds,
-			*tfSeconds,
-			*tfSeconds,
-			underlying,
-			expiry,
-			strike,
-			optionType,
-			effectiveStart,
-			effectiveEnd,
-			sessionStart,
-			*tfSeconds,
-			*tfSeconds,
-			effectiveEnd,
-		)
-		if err != nil {
-			return nil, err
-		}
+	data, err := buildBars(src, cal, from, to, *spec)
+	if err != nil || market == nil {
+		return data, err
+	}
 
-		for rows.Next() {
-			var c candle
-			if err := rows.Scan(&c.Ts, &c.Open, &c.High, &c.Low, &c.Close); err != nil {
-				rows.Close()
-				return nil, err
-			}
-			all = append(all, c)
-		}
+	sessions, _ := cal.Sessions(from, to)
 
-		rows.Close()
-		day = day.AddDate(0, 0, 1)
+	expiries, err := sessionExpiries(underlying, sessions, expiryRule)
+	if err != nil {
+		return nil, err
 	}
 
-	out := models.ColumnarOHLC{
-		Ts:    make([]time.Time, 0, len(all)),
-		Open:  make([]float64, 0, len(all)),
-		High:  make([]float64, 0, len(all)),
-		Low:   make([]float64, 0, len(all)),
-		Close: make([]float64, 0, len(all)),
+	expiryOn := map[string]time.Time{}
+	for i, s := range sessions {
+		if e, err := time.ParseInLocation("2006-01-02", expiries[i], cal.Location()); err == nil {
+			expiryOn[s.Date.Format("2006-01-02")] = e
+		}
 	}
 
-	for _, c := range all {
-		out.Ts = append(out.Ts, c.Ts)
-		out.Open = append(out.Open, c.Open)
-		out.High = append(out.High, c.High)
-		out.Low = append(out.Low, c.Low)
-		out.Close = append(out.Close, c.Close)
+	// iv is solved on time bars only; the controller rejects the rest
+	if err := contractAnalytics(&data, src, cal, from, to, *spec, underlying, expiryOn, strike, optionType, *market, greeks); err != nil {
+		return nil, err
 	}
 
-	return out, nil
+	return data, nil
 }
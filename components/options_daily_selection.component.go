@@ -0,0 +1,235 @@
+package components
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+)
+
+// Selection reasons: the rule that separated the chosen contract from the
+// runner-up.
+const (
+	ReasonOnlyCandidate  = "only_candidate"
+	ReasonClosestPremium = "closest_premium"
+	ReasonLowerLvl       = "lower_moneyness_lvl"
+	ReasonLowerStrike    = "lower_strike"
+)
+
+// premiumPick is a contract chosen by premium.
+type premiumPick struct {
+	Quote      chainQuote
+	Lvl        int     // listed strikes away from ATM
+	Gap        float64 // |ltp - target|
+	Reason     string
+	Candidates int
+	RunnerUp   *chainQuote
+	RunnerGap  float64
+}
+
+// pickByPremium chooses, among quotes of optionType, the one whose ltp is
+// closest to target, then the one fewer listed strikes from atm, then the
+// lower strike. ok is false when no quote of optionType is given.
+func pickByPremium(quotes []chainQuote, optionType string, target float64, atm uint32) (premiumPick, bool) {
+	// quotes come ordered by strike
+	strikes := []uint32{}
+	for _, q := range quotes {
+		if len(strikes) == 0 || strikes[len(strikes)-1] != q.Strike {
+			strikes = append(strikes, q.Strike)
+		}
+	}
+
+	index := func(strike uint32) int {
+		return sort.Search(len(strikes), func(i int) bool { return strikes[i] >= strike })
+	}
+
+	// listed strikes from atm to the strike, the strike counted, atm not
+	lvl := func(strike uint32) int {
+		if strike >= atm {
+			return index(strike+1) - index(atm+1)
+		}
+		return index(atm) - index(strike)
+	}
+
+	type candidate struct {
+		q   chainQuote
+		lvl int
+		gap float64
+	}
+
+	candidates := []candidate{}
+	for _, q := range quotes {
+		if q.OptionType != optionType {
+			continue
+		}
+		// gaps are compared on a micro grid, so prices on either side of
+		// the target by the same tick tie
+		gap := math.Round(math.Abs(q.Ltp-target)*1e6) / 1e6
+		candidates = append(candidates, candidate{q, lvl(q.Strike), gap})
+	}
+
+	if len(candidates) == 0 {
+		return premiumPick{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.gap != b.gap:
+			return a.gap < b.gap
+		case a.lvl != b.lvl:
+			return a.lvl < b.lvl
+		default:
+			return a.q.Strike < b.q.Strike
+		}
+	})
+
+	best := candidates[0]
+	pick := premiumPick{
+		Quote:      best.q,
+		Lvl:        best.lvl,
+		Gap:        best.gap,
+		Reason:     ReasonOnlyCandidate,
+		Candidates: len(candidates),
+		RunnerGap:  math.NaN(),
+	}
+
+	if len(candidates) > 1 {
+		runner := candidates[1]
+		pick.RunnerUp = &runner.q
+		pick.RunnerGap = runner.gap
+
+		switch {
+		case best.gap != runner.gap:
+			pick.Reason = ReasonClosestPremium
+		case best.lvl != runner.lvl:
+			pick.Reason = ReasonLowerLvl
+		default:
+			pick.Reason = ReasonLowerStrike
+		}
+	}
+
+	return pick, true
+}
+
+// moneynessOf labels a strike against atm for optionType.
+func moneynessOf(strike, atm uint32, optionType string) string {
+	switch {
+	case strike == atm:
+		return "ATM"
+	case (strike < atm) == (optionType == InstrumentCall):
+		return "ITM"
+	default:
+		return "OTM"
+	}
+}
+
+// GetDailyPremiumSelection picks, for every trading day from from to to,
+// one contract per option type: the one whose last premium at the time
+// of day at is closest to target, on the expiry rule resolved per day.
+// Quotes are the chain as of at in that day's session, as
+// /options/chain sees it. Days without a quote are reported as skipped.
+func GetDailyPremiumSelection(
+	underlying string,
+	optionTypes []string,
+	target float64,
+	expiryRule ExpiryRule,
+	from time.Time,
+	to time.Time,
+	at time.Duration,
+) (models.ContractSelection, error) {
+
+	out := models.ContractSelection{
+		Date:           []string{},
+		OptionType:     []string{},
+		Expiry:         []string{},
+		Strike:         []uint32{},
+		Ltp:            models.FloatColumn{},
+		LtpTs:          []time.Time{},
+		Spot:           models.FloatColumn{},
+		AtmStrike:      []uint32{},
+		Moneyness:      []string{},
+		MoneynessLvl:   []int{},
+		PremiumGap:     models.FloatColumn{},
+		Candidates:     []int{},
+		Reason:         []string{},
+		RunnerUpStrike: []uint32{},
+		RunnerUpGap:    models.FloatColumn{},
+		Skipped:        []models.SkippedSession{},
+	}
+
+	cal := calendar.For(underlying)
+
+	sessions, _ := cal.Sessions(from, to.AddDate(0, 0, 1))
+
+	expiries, err := sessionExpiries(underlying, sessions, expiryRule)
+	if err != nil {
+		return out, err
+	}
+
+	for i, s := range sessions {
+		date := s.Date.Format("2006-01-02")
+
+		skip := func(reason string) {
+			out.Skipped = append(out.Skipped, models.SkippedSession{Date: date, Reason: reason})
+		}
+
+		when := s.Date.Add(at)
+		if when.Before(s.Open) || !when.Before(s.Close) {
+			skip("time outside session")
+			continue
+		}
+
+		if expiries[i] == "" {
+			skip("no expiry listed")
+			continue
+		}
+
+		expiry, err := time.ParseInLocation("2006-01-02", expiries[i], cal.Location())
+		if err != nil {
+			return out, err
+		}
+
+		quotes, err := loadChainQuotes(underlying, &expiry, when)
+		if err != nil {
+			return out, err
+		}
+
+		latest := freshest(quotes)
+
+		for _, optionType := range optionTypes {
+			pick, ok := pickByPremium(quotes, optionType, target, latest.AtmStrike)
+			if !ok {
+				skip(optionType + ": no quotes")
+				continue
+			}
+
+			q := pick.Quote
+
+			var runnerUp uint32
+			if pick.RunnerUp != nil {
+				runnerUp = pick.RunnerUp.Strike
+			}
+
+			out.Date = append(out.Date, date)
+			out.OptionType = append(out.OptionType, optionType)
+			out.Expiry = append(out.Expiry, expiries[i])
+			out.Strike = append(out.Strike, q.Strike)
+			out.Ltp = append(out.Ltp, q.Ltp)
+			out.LtpTs = append(out.LtpTs, q.Ts)
+			out.Spot = append(out.Spot, latest.Spot)
+			out.AtmStrike = append(out.AtmStrike, latest.AtmStrike)
+			out.Moneyness = append(out.Moneyness, moneynessOf(q.Strike, latest.AtmStrike, optionType))
+			out.MoneynessLvl = append(out.MoneynessLvl, pick.Lvl)
+			out.PremiumGap = append(out.PremiumGap, pick.Gap)
+			out.Candidates = append(out.Candidates, pick.Candidates)
+			out.Reason = append(out.Reason, pick.Reason)
+			out.RunnerUpStrike = append(out.RunnerUpStrike, runnerUp)
+			out.RunnerUpGap = append(out.RunnerUpGap, pick.RunnerGap)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package components
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"quant-read-api/analytics"
+	"quant-read-api/models"
+)
+
+// GetOptionContractsByDelta returns, per option type, the count strikes
+// of expiry whose delta is nearest target as of at. Every quote of the
+// chain as of at is solved for IV against the spot printed on its own
+// tick, and its delta taken at that IV. target is the call delta; puts
+// are matched on -target. Ties go to the lower strike; quotes whose IV
+// does not solve are left out.
+func GetOptionContractsByDelta(
+	underlying string,
+	expiry time.Time,
+	at time.Time,
+	optionTypes []string,
+	target float64,
+	count int,
+	market analytics.Market,
+) (models.DeltaSelection, error) {
+
+	out := models.DeltaSelection{
+		Expiry:     expiry.Format("2006-01-02"),
+		OptionType: []string{},
+		Rank:       []int{},
+		Strike:     []uint32{},
+		Ltp:        models.FloatColumn{},
+		LtpTs:      []time.Time{},
+		IV:         models.FloatColumn{},
+		Delta:      models.FloatColumn{},
+		DeltaGap:   models.FloatColumn{},
+	}
+
+	quotes, err := loadChainQuotes(underlying, &expiry, at)
+	if err != nil {
+		return out, err
+	}
+	if len(quotes) == 0 {
+		return out, ErrNoQuotes
+	}
+
+	solver := newIVSolver(underlying, market, true)
+
+	latest := freshest(quotes)
+	out.Spot = latest.Spot
+	out.SpotTs = latest.Ts
+	out.AtmStrike = latest.AtmStrike
+	out.DaysToExpiry = solver.closeOf(expiry).Sub(at).Hours() / 24
+
+	type candidate struct {
+		q   chainQuote
+		p   optionPoint
+		gap float64
+	}
+
+	for _, optionType := range optionTypes {
+		want := target
+		if optionType == InstrumentPut {
+			want = -target
+		}
+
+		candidates := []candidate{}
+		for _, q := range quotes {
+			if q.OptionType != optionType {
+				continue
+			}
+
+			p := solver.solve(q.Ts, q.Expiry, float64(q.Strike), q.OptionType, q.Ltp, q.Spot)
+			if p.Status != analytics.StatusOK || math.IsNaN(p.Delta) {
+				continue
+			}
+
+			candidates = append(candidates, candidate{q, p, math.Abs(p.Delta - want)})
+		}
+
+		out.Candidates += len(candidates)
+
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if a.gap != b.gap {
+				return a.gap < b.gap
+			}
+			return a.q.Strike < b.q.Strike
+		})
+
+		for rank, c := range candidates[:min(count, len(candidates))] {
+			out.OptionType = append(out.OptionType, optionType)
+			out.Rank = append(out.Rank, rank+1)
+			out.Strike = append(out.Strike, c.q.Strike)
+			out.Ltp = append(out.Ltp, c.q.Ltp)
+			out.LtpTs = append(out.LtpTs, c.q.Ts)
+			out.IV = append(out.IV, c.p.IV)
+			out.Delta = append(out.Delta, c.p.Delta)
+			out.DeltaGap = append(out.DeltaGap, c.gap)
+		}
+	}
+
+	return out, nil
+}
@@ -1,11 +1,23 @@
 package components
 
 import (
+	"strconv"
 	"time"
 
+	"quant-read-api/calendar"
+	"quant-read-api/models"
 	"quant-read-api/services"
 )
 
+// Expiry kinds of GetOptionExpiries.
+const (
+	KindWeekly  = "weekly"
+	KindMonthly = "monthly"
+)
+
+// GetOptionExpiries returns every listed expiry of underlying. With from
+// and to, only expiries that traded between them, both inclusive, are
+// kept (the v1 contract).
 func GetOptionExpiries(
 	underlying string,
 	from *time.Time,
@@ -45,5 +57,435 @@ func GetOptionExpiries(
 		expiries = append(expiries, expiry.Format("2006-01-02"))
 	}
 
-	return expiries, nil
+	return expiries, rows.Err()
+}
+
+// GetOptionExpiryDetail returns every listed expiry of underlying with its
+// kind, trading window, strike coverage and holiday shift. With from and
+// to, only expiries that traded in [from, to) are kept; their figures
+// still cover the contract's whole life.
+func GetOptionExpiryDetail(
+	underlying string,
+	from *time.Time,
+	to *time.Time,
+) (models.OptionExpiries, error) {
+
+	out := models.OptionExpiries{
+		Expiries:       []string{},
+		Kind:           []string{},
+		FirstTs:        []time.Time{},
+		LastTs:         []time.Time{},
+		StrikeMin:      []uint32{},
+		StrikeMax:      []uint32{},
+		StrikeCount:    []uint64{},
+		StrikeStep:     []uint32{},
+		HolidayShifted: []*bool{},
+	}
+
+	db := services.GetClickHouse()
+
+	// with a window, only the expiries that traded in it are aggregated
+	kept := ""
+	args := []any{underlying}
+
+	if from != nil && to != nil {
+		kept = `
+		  AND expiry IN (
+			SELECT DISTINCT expiry
+			FROM options_moneyness
+			WHERE underlying = ?
+			  AND ts >= ?
+			  AND ts < ?
+		  )`
+		args = append(args, underlying, *from, *to)
+	}
+
+	query := `
+		SELECT
+			expiry,
+			min(ts),
+			max(ts),
+			min(strike),
+			max(strike),
+			uniqExact(strike),
+			toUInt32(arrayMin(arrayPopFront(arrayDifference(arraySort(groupUniqArray(strike))))))
+		FROM options_moneyness
+		WHERE underlying = ?` + kept + `
+		GROUP BY expiry
+		ORDER BY expiry
+	`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	type expiryRow struct {
+		Expiry               time.Time
+		FirstTs, LastTs      time.Time
+		StrikeMin, StrikeMax uint32
+		StrikeCount          uint64
+		StrikeStep           uint32
+	}
+
+	all := []expiryRow{}
+	listed := []time.Time{}
+
+	for rows.Next() {
+		var r expiryRow
+		if err := rows.Scan(
+			&r.Expiry,
+			&r.FirstTs,
+			&r.LastTs,
+			&r.StrikeMin,
+			&r.StrikeMax,
+			&r.StrikeCount,
+			&r.StrikeStep,
+		); err != nil {
+			return out, err
+		}
+		all = append(all, r)
+		listed = append(listed, r.Expiry)
+	}
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+
+	// the kind needs every expiry listed in the months kept
+	if kept != "" && len(listed) > 0 {
+		months := []uint32{}
+		for _, e := range listed {
+			m := uint32(e.Year()*100 + int(e.Month()))
+			if len(months) == 0 || months[len(months)-1] != m {
+				months = append(months, m)
+			}
+		}
+
+		if listed, err = monthExpiries(underlying, months); err != nil {
+			return out, err
+		}
+	}
+
+	monthly := monthlyExpiries(underlying, listed)
+
+	for _, r := range all {
+		date := r.Expiry.Format("2006-01-02")
+
+		kind := KindWeekly
+		if monthly[date] {
+			kind = KindMonthly
+		}
+
+		var shifted *bool
+		if s, known := calendar.ExpiryShifted(underlying, r.Expiry, kind == KindMonthly); known {
+			shifted = &s
+		}
+
+		out.Expiries = append(out.Expiries, date)
+		out.Kind = append(out.Kind, kind)
+		out.FirstTs = append(out.FirstTs, r.FirstTs)
+		out.LastTs = append(out.LastTs, r.LastTs)
+		out.StrikeMin = append(out.StrikeMin, r.StrikeMin)
+		out.StrikeMax = append(out.StrikeMax, r.StrikeMax)
+		out.StrikeCount = append(out.StrikeCount, r.StrikeCount)
+		out.StrikeStep = append(out.StrikeStep, r.StrikeStep)
+		out.HolidayShifted = append(out.HolidayShifted, shifted)
+	}
+
+	return out, nil
+}
+
+// monthExpiries returns every expiry listed in the given YYYYMM months,
+// in order.
+func monthExpiries(underlying string, months []uint32) ([]time.Time, error) {
+	query := `
+		SELECT DISTINCT expiry
+		FROM options_moneyness
+		WHERE underlying = ?
+		  AND has(?, toYYYYMM(expiry))
+		ORDER BY expiry
+	`
+
+	rows, err := services.GetClickHouse().Query(query, underlying, months)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expiries := make([]time.Time, 0)
+
+	for rows.Next() {
+		var expiry time.Time
+		if err := rows.Scan(&expiry); err != nil {
+			return nil, err
+		}
+		expiries = append(expiries, expiry)
+	}
+
+	return expiries, rows.Err()
+}
+
+// listedExpiry is an expiry and the trading day its contracts first
+// printed.
+type listedExpiry struct {
+	Expiry time.Time
+	Listed time.Time
+}
+
+// listedBy reports whether the expiry had printed by the day of day.
+func (e listedExpiry) listedBy(day time.Time) bool {
+	return dayNumber(e.Listed) <= dayNumber(day)
+}
+
+// listedExpiries returns every expiry on or after the day of from that
+// printed by the day of to, nearest first. Callers check listedBy per
+// day: an expiry only counts from its first print on.
+func listedExpiries(underlying string, from, to time.Time) ([]listedExpiry, error) {
+	db := services.GetClickHouse()
+	tz := calendar.For(underlying).Location().String()
+
+	query := `
+		SELECT
+			expiry,
+			toDate(min(ts), '` + tz + `') AS listed
+		FROM options_moneyness
+		WHERE underlying = ?
+		  AND expiry >= toDate(?)
+		GROUP BY expiry
+		HAVING listed <= toDate(?)
+		ORDER BY expiry
+	`
+
+	rows, err := db.Query(query, underlying, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expiries := make([]listedExpiry, 0)
+
+	for rows.Next() {
+		var e listedExpiry
+		if err := rows.Scan(&e.Expiry, &e.Listed); err != nil {
+			return nil, err
+		}
+		expiries = append(expiries, e)
+	}
+
+	return expiries, rows.Err()
+}
+
+// Expiry rule kinds. Every option endpoint resolves its expiry through
+// an ExpiryRule, one expiry per trading day.
+const (
+	ExpiryNearest = "nearest" // first expiry on or after the trading day
+	ExpiryNext    = "next"    // the one after it
+	ExpiryWeekly  = "weekly"  // expiries that are not their month's monthly
+	ExpiryMonthly = "monthly" // the exchange's monthly expiry of a month
+	ExpiryAll     = "all"     // every listed expiry; row queries only
+)
+
+// ExpiryRule picks an expiry for a trading day: the Nth of the listed
+// expiries of a kind, within DteMin..DteMax calendar days of the day, or
+// a literal Date.
+type ExpiryRule struct {
+	Kind   string
+	Date   string // YYYY-MM-DD; set for a literal expiry, Kind is then ""
+	Nth    int    // 1-based; ExpiryNext is the 2nd nearest
+	DteMin *int
+	DteMax *int
+}
+
+// ExpiryOn is the rule for one literal expiry.
+func ExpiryOn(date string) ExpiryRule {
+	return ExpiryRule{Date: date}
+}
+
+// String renders the rule for meta, e.g. "monthly,nth=2,dte_min=7".
+func (r ExpiryRule) String() string {
+	if r.Date != "" {
+		return r.Date
+	}
+
+	s := r.Kind
+	if r.Nth > 1 && r.Kind != ExpiryNext {
+		s += ",nth=" + strconv.Itoa(r.Nth)
+	}
+	if r.DteMin != nil {
+		s += ",dte_min=" + strconv.Itoa(*r.DteMin)
+	}
+	if r.DteMax != nil {
+		s += ",dte_max=" + strconv.Itoa(*r.DteMax)
+	}
+	return s
+}
+
+// dteOK reports whether an expiry dte calendar days out passes the
+// rule's bounds.
+func (r ExpiryRule) dteOK(dte int) bool {
+	return (r.DteMin == nil || dte >= *r.DteMin) && (r.DteMax == nil || dte <= *r.DteMax)
+}
+
+// pick resolves the rule on day among the expiries listed by then,
+// nearest first. monthly holds the monthly expiry of every month. It
+// returns "" when nothing qualifies.
+func (r ExpiryRule) pick(day time.Time, listed []listedExpiry, monthly map[string]bool) string {
+	n := max(r.Nth, 1)
+	if r.Kind == ExpiryNext {
+		n = 2
+	}
+
+	today := dayNumber(day)
+
+	for _, l := range listed {
+		e := l.Expiry
+
+		dte := dayNumber(e) - today
+		if dte < 0 || !r.dteOK(dte) || !l.listedBy(day) {
+			continue
+		}
+
+		date := e.Format("2006-01-02")
+		switch r.Kind {
+		case ExpiryMonthly:
+			if !monthly[date] {
+				continue
+			}
+		case ExpiryWeekly:
+			if monthly[date] {
+				continue
+			}
+		}
+
+		if n--; n == 0 {
+			return date
+		}
+	}
+
+	return ""
+}
+
+// dayNumber counts calendar days since the epoch for the date of t in its
+// own location.
+func dayNumber(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// monthlyExpiries marks the monthly expiry of every month of listed, in
+// order: the exchange's monthly expiry where the registry has a weekday
+// schedule for the month, else the last of listed in the month.
+func monthlyExpiries(underlying string, listed []time.Time) map[string]bool {
+	out := map[string]bool{}
+	for i, e := range listed {
+		lastOfMonth := i+1 == len(listed) || listed[i+1].Month() != e.Month() || listed[i+1].Year() != e.Year()
+		if !lastOfMonth {
+			continue
+		}
+
+		if monthly, ok := calendar.MonthlyExpiry(underlying, e); ok {
+			out[monthly.Format("2006-01-02")] = true
+		} else {
+			out[e.Format("2006-01-02")] = true
+		}
+	}
+	return out
+}
+
+// sessionExpiries resolves rule for every session. A session with
+// nothing qualifying resolves to "". ExpiryAll has no single expiry and
+// resolves to "" everywhere; row queries go through expiryFilter.
+func sessionExpiries(underlying string, sessions []calendar.Session, rule ExpiryRule) ([]string, error) {
+	out := make([]string, len(sessions))
+	if len(sessions) == 0 || rule.Kind == ExpiryAll {
+		return out, nil
+	}
+
+	if rule.Date != "" {
+		for i := range out {
+			out[i] = rule.Date
+		}
+		return out, nil
+	}
+
+	listed, err := listedExpiries(underlying, sessions[0].Date, sessions[len(sessions)-1].Date)
+	if err != nil {
+		return nil, err
+	}
+
+	expiries := make([]time.Time, len(listed))
+	for i, l := range listed {
+		expiries[i] = l.Expiry
+	}
+	monthly := monthlyExpiries(underlying, expiries)
+
+	for i, s := range sessions {
+		out[i] = rule.pick(s.Date, listed, monthly)
+	}
+
+	return out, nil
+}
+
+// ResolveExpiry resolves rule on the day of at, for endpoints that read
+// one point in time. It returns "" when nothing qualifies.
+func ResolveExpiry(underlying string, at time.Time, rule ExpiryRule) (string, error) {
+	at = at.In(calendar.For(underlying).Location())
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+
+	expiries, err := sessionExpiries(underlying, []calendar.Session{{Date: day}}, rule)
+	if err != nil {
+		return "", err
+	}
+	return expiries[0], nil
+}
+
+// expiryFilter is the SQL condition keeping the rows of options_moneyness
+// on the expiry rule resolves to for their own trading day in [from, to].
+// ExpiryAll keeps every expiry within the rule's DTE bounds.
+func expiryFilter(
+	underlying string,
+	cal *calendar.Calendar,
+	from time.Time,
+	to time.Time,
+	rule ExpiryRule,
+) (string, []any, error) {
+
+	tz := cal.Location().String()
+
+	if rule.Date != "" {
+		return "expiry = toDate(?)", []any{rule.Date}, nil
+	}
+
+	if rule.Kind == ExpiryAll {
+		sql := "1 = 1"
+		args := []any{}
+		if rule.DteMin != nil {
+			sql += " AND dateDiff('day', toDate(ts, '" + tz + "'), expiry) >= ?"
+			args = append(args, *rule.DteMin)
+		}
+		if rule.DteMax != nil {
+			sql += " AND dateDiff('day', toDate(ts, '" + tz + "'), expiry) <= ?"
+			args = append(args, *rule.DteMax)
+		}
+		return sql, args, nil
+	}
+
+	sessions, _ := cal.Sessions(from, to)
+	if len(sessions) == 0 {
+		return "1 = 0", nil, nil
+	}
+
+	expiries, err := sessionExpiries(underlying, sessions, rule)
+	if err != nil {
+		return "", nil, err
+	}
+
+	days := make([]uint32, len(sessions))
+	for i, s := range sessions {
+		days[i] = yyyymmdd(s.Date)
+	}
+
+	sql := "toString(expiry) = arrayElement(?, indexOf(?, toYYYYMMDD(ts, '" + tz + "')))"
+
+	return sql, []any{expiries, days}, nil
 }
@@ -0,0 +1,276 @@
+package components
+
+import (
+	"math"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// strikeCandle is one strike's candle in one bucket.
+type strikeCandle struct {
+	Strike                 uint32
+	Open, High, Low, Close float64
+	AtmStrike              uint32
+}
+
+// GetOptionPremiumSeries stitches the candles of the strike whose premium
+// is closest to target, on the expiry rule resolved per session.
+//
+// The strike is chosen at the start of a bucket from every strike's last
+// close in that session so far, so a choice never sees the bucket it is
+// used for; ties go to the strike nearer ATM, then the lower one. With no
+// reselect times the choice is remade every bucket; otherwise at the
+// first bucket starting at or after each time of day, and held between
+// them. Buckets before a session's first choice are left out.
+func GetOptionPremiumSeries(
+	underlying string,
+	optionType string,
+	target float64,
+	expiryRule ExpiryRule,
+	reselect []time.Duration,
+	from time.Time,
+	to time.Time,
+	spec ResampleSpec,
+) (models.OptionPremiumSeries, error) {
+
+	out := models.OptionPremiumSeries{
+		OptionRollingOHLC: models.OptionRollingOHLC{
+			Ts:     []time.Time{},
+			Open:   []float64{},
+			High:   []float64{},
+			Low:    []float64{},
+			Close:  []float64{},
+			Expiry: []string{},
+			Strike: []uint32{},
+			Rolled: []bool{},
+		},
+		Switches: []models.PremiumSwitch{},
+	}
+
+	cal := calendar.For(underlying)
+
+	b, ok := newBucketing(cal, from, to, spec)
+	if !ok {
+		return out, nil
+	}
+
+	sessions := make([]calendar.Session, len(b.Windows))
+	for i, w := range b.Windows {
+		sessions[i] = w.Session
+	}
+
+	expiries, err := sessionExpiries(underlying, sessions, expiryRule)
+	if err != nil {
+		return out, err
+	}
+
+	query := b.With + `
+	SELECT
+		bucket_ts,
+		strike,
+		argMin(price, tick_ts)      AS open,
+		max(price)                  AS high,
+		min(price)                  AS low,
+		argMax(price, tick_ts)      AS close,
+		argMax(atm_strike, tick_ts) AS atm
+	FROM
+	(
+		SELECT
+			ts AS tick_ts,
+			ltp AS price,
+			strike,
+			atm_strike,
+			` + b.Columns + `
+		FROM options_moneyness
+		WHERE underlying = ?
+		  AND option_type = ?
+		  AND toString(expiry) = arrayElement(?, session_idx)
+		  AND ` + b.Where + `
+	)
+	GROUP BY bucket_ts, strike
+	ORDER BY bucket_ts, strike
+	`
+
+	args := append([]any{}, b.Args...)
+	args = append(args, underlying, optionType, expiries)
+	args = append(args, b.RangeArgs()...)
+
+	rows, err := services.GetClickHouse().Query(query, args...)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	candles := map[int64][]strikeCandle{}
+
+	for rows.Next() {
+		var bucket int64
+		var c strikeCandle
+
+		if err := rows.Scan(&bucket, &c.Strike, &c.Open, &c.High, &c.Low, &c.Close, &c.AtmStrike); err != nil {
+			return out, err
+		}
+
+		candles[bucket] = append(candles[bucket], c)
+	}
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+
+	fill := spec.Fill
+	if fill == "" {
+		fill = FillNone
+	}
+	if fill != FillNone {
+		out.Filled = []bool{}
+	}
+	if spec.Partial == PartialFlag {
+		out.Partial = []bool{}
+	}
+
+	loc := cal.Location()
+
+	expiryOf := map[uint32]string{}
+	for i, s := range sessions {
+		expiryOf[yyyymmdd(s.Date)] = expiries[i]
+	}
+
+	var (
+		day      uint32
+		dayStart time.Time
+		expiry   string
+		next     int                // next reselect time of the day
+		pending  bool               // a choice is due
+		chosen   bool               // a strike was chosen this session
+		rolled   bool               // switched since the last candle
+		last     map[uint32]float64 // strike -> last close this session
+		atm      uint32
+
+		heldExpiry string
+		heldStrike uint32
+		prevClose  = math.NaN()
+	)
+
+	for _, g := range bucketGrid(b.Windows, b.Periods, spec) {
+		start := time.Unix(g.Start, 0).In(loc)
+
+		if d := yyyymmdd(start); d != day {
+			day = d
+			dayStart = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+			expiry = expiryOf[d]
+			next = 0
+			pending = false
+			chosen = false
+			last = map[uint32]float64{}
+		}
+
+		if reselect == nil {
+			pending = true
+		}
+		for next < len(reselect) && !start.Before(dayStart.Add(reselect[next])) {
+			next++
+			pending = true
+		}
+
+		if pending && len(last) > 0 {
+			pending = false
+			chosen = true
+
+			if s := closestPremium(last, target, atm); s != heldStrike || expiry != heldExpiry {
+				out.Switches = append(out.Switches, models.PremiumSwitch{
+					Ts:         start.Format(time.RFC3339),
+					FromExpiry: heldExpiry,
+					FromStrike: heldStrike,
+					Expiry:     expiry,
+					ToStrike:   s,
+					Premium:    last[s],
+				})
+
+				heldExpiry, heldStrike = expiry, s
+				rolled = true
+				prevClose = math.NaN()
+			}
+		}
+
+		var (
+			c      strikeCandle
+			traded bool
+		)
+		for _, sc := range candles[g.Start] {
+			last[sc.Strike] = sc.Close
+			atm = sc.AtmStrike
+			if chosen && sc.Strike == heldStrike {
+				c, traded = sc, true
+			}
+		}
+
+		if !chosen {
+			continue
+		}
+		if g.dropped(spec.Partial) {
+			continue
+		}
+
+		switch {
+		case traded:
+		case fill == FillForward && !math.IsNaN(prevClose):
+			c = strikeCandle{Open: prevClose, High: prevClose, Low: prevClose, Close: prevClose}
+		case fill == FillNull:
+			nan := math.NaN()
+			c = strikeCandle{Open: nan, High: nan, Low: nan, Close: nan}
+		default:
+			continue
+		}
+
+		if traded {
+			prevClose = c.Close
+		}
+
+		label := g.Start
+		if spec.Label == LabelEnd {
+			label = g.End
+		}
+
+		out.Ts = append(out.Ts, time.Unix(label, 0).In(loc))
+		out.Open = append(out.Open, c.Open)
+		out.High = append(out.High, c.High)
+		out.Low = append(out.Low, c.Low)
+		out.Close = append(out.Close, c.Close)
+		out.Expiry = append(out.Expiry, heldExpiry)
+		out.Strike = append(out.Strike, heldStrike)
+		out.Rolled = append(out.Rolled, rolled)
+
+		if out.Filled != nil {
+			out.Filled = append(out.Filled, !traded)
+		}
+		if out.Partial != nil {
+			out.Partial = append(out.Partial, g.Partial)
+		}
+
+		rolled = false
+	}
+
+	return out, nil
+}
+
+// closestPremium picks the strike whose last close is closest to target,
+// then the one nearer atm, then the lower one.
+func closestPremium(last map[uint32]float64, target float64, atm uint32) uint32 {
+	var best uint32
+	bestGap, bestAtm := math.Inf(1), math.Inf(1)
+
+	for strike, close := range last {
+		gap := math.Abs(close - target)
+		fromAtm := math.Abs(float64(strike) - float64(atm))
+
+		if gap < bestGap ||
+			(gap == bestGap && (fromAtm < bestAtm || (fromAtm == bestAtm && strike < best))) {
+			best, bestGap, bestAtm = strike, gap, fromAtm
+		}
+	}
+
+	return best
+}
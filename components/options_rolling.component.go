@@ -0,0 +1,302 @@
+package components
+
+import (
+	"math"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// moneynessFilter selects one moneyness slice, e.g. ATM or OTM 2.
+func moneynessFilter(moneyness string, lvl int) (string, []any) {
+	if moneyness == "ATM" {
+		return "moneyness = 'ATM' AND moneyness_lvl = 0", nil
+	}
+	return "moneyness = ? AND moneyness_lvl = ?", []any{moneyness, lvl}
+}
+
+// GetOptionRolling follows one moneyness slice of one option type through
+// the day, switching strikes as atm_strike moves, on the expiry rule
+// resolved per session. It returns raw ticks when spec is nil, candles
+// otherwise.
+func GetOptionRolling(
+	underlying string,
+	optionType string,
+	moneyness string,
+	moneynessLvl int,
+	expiryRule ExpiryRule,
+	from time.Time,
+	to time.Time,
+	spec *ResampleSpec,
+) (any, error) {
+
+	cal := calendar.For(underlying)
+
+	moneynessSQL, moneynessArgs := moneynessFilter(moneyness, moneynessLvl)
+
+	filter := `underlying = ?
+		  AND option_type = ?
+		  AND ` + moneynessSQL
+
+	filterArgs := append([]any{underlying, optionType}, moneynessArgs...)
+
+	if spec == nil {
+		return optionRollingRaw(cal, filter, filterArgs, underlying, expiryRule, from, to)
+	}
+
+	out := models.OptionRollingOHLC{
+		Ts:     []time.Time{},
+		Open:   []float64{},
+		High:   []float64{},
+		Low:    []float64{},
+		Close:  []float64{},
+		Expiry: []string{},
+		Strike: []uint32{},
+		Rolled: []bool{},
+	}
+
+	b, ok := newBucketing(cal, from, to, *spec)
+	if !ok {
+		return out, nil
+	}
+
+	sessions := make([]calendar.Session, len(b.Windows))
+	for i, w := range b.Windows {
+		sessions[i] = w.Session
+	}
+
+	expiries, err := sessionExpiries(underlying, sessions, expiryRule)
+	if err != nil {
+		return nil, err
+	}
+
+	query := b.With + `
+	SELECT
+		bucket_ts,
+		argMin(price, tick_ts)           AS open,
+		max(price)                       AS high,
+		min(price)                       AS low,
+		argMax(price, tick_ts)           AS close,
+		argMin(strike, tick_ts)          AS first_strike,
+		argMax(strike, tick_ts)          AS last_strike,
+		uniqExact(strike)                AS strikes,
+		argMin(contract_expiry, tick_ts) AS first_expiry,
+		argMax(contract_expiry, tick_ts) AS last_expiry,
+		uniqExact(contract_expiry)       AS contract_expiries
+	FROM
+	(
+		SELECT
+			ts AS tick_ts,
+			ltp AS price,
+			strike,
+			toString(expiry) AS contract_expiry,
+			` + b.Columns + `
+		FROM options_moneyness
+		WHERE ` + filter + `
+		  AND contract_expiry = arrayElement(?, session_idx)
+		  AND ` + b.Where + `
+	)
+	GROUP BY bucket_ts
+	ORDER BY bucket_ts
+	`
+
+	args := append([]any{}, b.Args...)
+	args = append(args, filterArgs...)
+	args = append(args, expiries)
+	args = append(args, b.RangeArgs()...)
+
+	rows, err := services.GetClickHouse().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type candle struct {
+		Open, High, Low, Close  float64
+		FirstStrike, LastStrike uint32
+		Strikes                 uint64
+		FirstExpiry, LastExpiry string
+		Expiries                uint64
+	}
+
+	candles := map[int64]candle{}
+
+	for rows.Next() {
+		var bucket int64
+		var c candle
+
+		if err := rows.Scan(
+			&bucket,
+			&c.Open,
+			&c.High,
+			&c.Low,
+			&c.Close,
+			&c.FirstStrike,
+			&c.LastStrike,
+			&c.Strikes,
+			&c.FirstExpiry,
+			&c.LastExpiry,
+			&c.Expiries,
+		); err != nil {
+			return nil, err
+		}
+
+		candles[bucket] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fill := spec.Fill
+	if fill == "" {
+		fill = FillNone
+	}
+	if fill != FillNone {
+		out.Filled = []bool{}
+	}
+	if spec.Partial == PartialFlag {
+		out.Partial = []bool{}
+	}
+
+	loc := cal.Location()
+	var prev *candle
+
+	for _, g := range bucketGrid(b.Windows, b.Periods, *spec) {
+		if g.dropped(spec.Partial) {
+			continue
+		}
+
+		c, ok := candles[g.Start]
+		rolled := false
+
+		switch {
+		case ok:
+			rolled = c.Strikes > 1 || c.Expiries > 1 ||
+				(prev != nil && (c.FirstStrike != prev.LastStrike || c.FirstExpiry != prev.LastExpiry))
+			prev = &c
+		case fill == FillForward && prev != nil:
+			c = candle{
+				Open:       prev.Close,
+				High:       prev.Close,
+				Low:        prev.Close,
+				Close:      prev.Close,
+				LastStrike: prev.LastStrike,
+				LastExpiry: prev.LastExpiry,
+			}
+		case fill == FillNull:
+			nan := math.NaN()
+			c = candle{Open: nan, High: nan, Low: nan, Close: nan}
+		default:
+			continue
+		}
+
+		label := g.Start
+		if spec.Label == LabelEnd {
+			label = g.End
+		}
+
+		out.Ts = append(out.Ts, time.Unix(label, 0).In(loc))
+		out.Open = append(out.Open, c.Open)
+		out.High = append(out.High, c.High)
+		out.Low = append(out.Low, c.Low)
+		out.Close = append(out.Close, c.Close)
+		out.Expiry = append(out.Expiry, c.LastExpiry)
+		out.Strike = append(out.Strike, c.LastStrike)
+		out.Rolled = append(out.Rolled, rolled)
+
+		if out.Filled != nil {
+			out.Filled = append(out.Filled, !ok)
+		}
+		if out.Partial != nil {
+			out.Partial = append(out.Partial, g.Partial)
+		}
+	}
+
+	return out, nil
+}
+
+// optionRollingRaw reads the ticks of a rolling slice inside the trading
+// sessions of [from, to).
+func optionRollingRaw(
+	cal *calendar.Calendar,
+	filter string,
+	filterArgs []any,
+	underlying string,
+	expiryRule ExpiryRule,
+	from time.Time,
+	to time.Time,
+) (models.OptionRollingRaw, error) {
+
+	out := models.OptionRollingRaw{
+		Ts:     []time.Time{},
+		Expiry: []string{},
+		Strike: []uint32{},
+		Ltp:    []float64{},
+		Spot:   []float64{},
+		Rolled: []bool{},
+	}
+
+	sessions, _ := cal.Sessions(from, to)
+	if len(sessions) == 0 {
+		return out, nil
+	}
+
+	expiries, err := sessionExpiries(underlying, sessions, expiryRule)
+	if err != nil {
+		return out, err
+	}
+
+	days := make([]uint32, len(sessions))
+	for i, s := range sessions {
+		days[i] = yyyymmdd(s.Date)
+	}
+
+	query := `
+		SELECT
+			ts,
+			toString(expiry) AS contract_expiry,
+			strike,
+			ltp,
+			spot_price
+		FROM options_moneyness
+		WHERE ` + filter + `
+		  AND ts >= ?
+		  AND ts < ?
+		  AND contract_expiry = arrayElement(?, indexOf(?, toYYYYMMDD(ts, '` + cal.Location().String() + `')))
+		ORDER BY ts
+	`
+
+	args := append([]any{}, filterArgs...)
+	args = append(args, from, to, expiries, days)
+
+	rows, err := services.GetClickHouse().Query(query, args...)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts time.Time
+		var expiry string
+		var strike uint32
+		var ltp, spot float64
+
+		if err := rows.Scan(&ts, &expiry, &strike, &ltp, &spot); err != nil {
+			return out, err
+		}
+
+		n := len(out.Ts)
+		rolled := n > 0 && (strike != out.Strike[n-1] || expiry != out.Expiry[n-1])
+
+		out.Ts = append(out.Ts, ts)
+		out.Expiry = append(out.Expiry, expiry)
+		out.Strike = append(out.Strike, strike)
+		out.Ltp = append(out.Ltp, ltp)
+		out.Spot = append(out.Spot, spot)
+		out.Rolled = append(out.Rolled, rolled)
+	}
+
+	return out, rows.Err()
+}
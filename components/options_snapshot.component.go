@@ -3,6 +3,8 @@ package components
 import (
 	"time"
 
+	"quant-read-api/analytics"
+	"quant-read-api/calendar"
 	"quant-read-api/models"
 	"quant-read-api/services"
 )
@@ -13,9 +15,11 @@ func GetOptionSnapshots(
 	moneyness string,
 	moneynessMode string,
 	moneynessLvl int,
-	expiryMode string,
+	expiryRule ExpiryRule,
 	from time.Time,
 	to time.Time,
+	market *analytics.Market,
+	greeks bool,
 ) ([]models.OptionSnapshot, error) {
 
 	db := services.GetClickHouse()
@@ -40,21 +44,11 @@ func GetOptionSnapshots(
 	}
 
 	// ----- expiry condition -----
-	var expirySQL string
-	if expiryMode == "all" {
-		expirySQL = ""
-	} else {
-		expirySQL = `
-			AND expiry = (
-				SELECT min(expiry)
-				FROM options_moneyness
-				WHERE underlying = ?
-				  AND option_type = ?
-				  AND expiry >= toDate(?)
-			)
-		`
-		args = append(args, underlying, optionType, from.Format("2006-01-02"))
+	expirySQL, expiryArgs, err := expiryFilter(underlying, calendar.For(underlying), from, to, expiryRule)
+	if err != nil {
+		return nil, err
 	}
+	args = append(args, expiryArgs...)
 
 	query = `
 		SELECT
@@ -74,7 +68,7 @@ func GetOptionSnapshots(
 		  AND option_type = ?
 		  AND ts BETWEEN ? AND ?
 		  ` + moneynessSQL + `
-		  ` + expirySQL + `
+		  AND ` + expirySQL + `
 		ORDER BY ts, strike, days_to_expiry
 	`
 
@@ -113,6 +107,13 @@ func GetOptionSnapshots(
 		}
 		out = append(out, r)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if market != nil {
+		snapshotAnalytics(out, *market, greeks)
+	}
 
 	return out, nil
 }
@@ -0,0 +1,245 @@
+package components
+
+import (
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// Leg selection for combined CE + PE premiums.
+const (
+	StraddleFixed = "fixed"    // both legs at one strike
+	StraddleATM   = "atm"      // both legs at the rolling ATM strike
+	Strangle      = "strangle" // both legs N strikes out of the money, rolling
+)
+
+// StraddleLegs picks the CE and PE legs.
+type StraddleLegs struct {
+	Mode   string
+	Strike uint32 // StraddleFixed
+	Width  int    // Strangle: moneyness_lvl of both OTM legs
+}
+
+// rolls reports whether the legs follow the ATM strike.
+func (l StraddleLegs) rolls() bool {
+	return l.Mode != StraddleFixed
+}
+
+// legFilter returns the WHERE condition matching both legs.
+func (l StraddleLegs) legFilter() (string, []any) {
+	switch l.Mode {
+	case StraddleFixed:
+		return "strike = ? AND option_type IN ('CE', 'PE')", []any{l.Strike}
+	case Strangle:
+		return "moneyness = 'OTM' AND moneyness_lvl = ? AND option_type IN ('CE', 'PE')", []any{l.Width}
+	default:
+		return "moneyness = 'ATM' AND moneyness_lvl = 0 AND option_type IN ('CE', 'PE')", nil
+	}
+}
+
+// straddleTable builds a subquery with one row per second on which either
+// leg printed. Each leg is as-of filled from its last print in the same
+// trading day, and seconds before both legs have printed are left out.
+// Rolling legs are paired only while both last printed against the same
+// ATM strike, so a roll never mixes a new-strike leg with a stale one.
+// It exposes ts, premium, ce_ltp, pe_ltp, ce_strike, pe_strike and
+// spot_price.
+func straddleTable(
+	underlying string,
+	legs StraddleLegs,
+	expiryRule ExpiryRule,
+	cal *calendar.Calendar,
+	from time.Time,
+	to time.Time,
+) (string, []any, bool, error) {
+
+	sessions, _ := cal.Sessions(from, to)
+	if len(sessions) == 0 {
+		return "", nil, false, nil
+	}
+
+	expiries, err := sessionExpiries(underlying, sessions, expiryRule)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	days := make([]uint32, len(sessions))
+	for i, s := range sessions {
+		days[i] = yyyymmdd(s.Date)
+	}
+
+	// read from the first session's open, so a leg that last printed
+	// before from still has a value at from
+	start := from
+	if sessions[0].Open.Before(start) {
+		start = sessions[0].Open
+	}
+
+	tz := cal.Location().String()
+	legSQL, legArgs := legs.legFilter()
+
+	sameRoll := ""
+	if legs.rolls() {
+		sameRoll = `
+		  AND ce_last_atm = pe_last_atm`
+	}
+
+	table := `(
+		SELECT
+			ts,
+			assumeNotNull(ce_last) + assumeNotNull(pe_last) AS premium,
+			assumeNotNull(ce_last)                          AS ce_ltp,
+			assumeNotNull(pe_last)                          AS pe_ltp,
+			assumeNotNull(ce_last_strike)                   AS ce_strike,
+			assumeNotNull(pe_last_strike)                   AS pe_strike,
+			spot_price
+		FROM
+		(
+			SELECT
+				ts,
+				anyLast(ce_tick) OVER legs        AS ce_last,
+				anyLast(pe_tick) OVER legs        AS pe_last,
+				anyLast(ce_tick_strike) OVER legs AS ce_last_strike,
+				anyLast(pe_tick_strike) OVER legs AS pe_last_strike,
+				anyLast(ce_tick_atm) OVER legs    AS ce_last_atm,
+				anyLast(pe_tick_atm) OVER legs    AS pe_last_atm,
+				spot_price
+			FROM
+			(
+				SELECT
+					ts,
+					anyIf(toNullable(ltp), option_type = 'CE')        AS ce_tick,
+					anyIf(toNullable(strike), option_type = 'CE')     AS ce_tick_strike,
+					anyIf(toNullable(atm_strike), option_type = 'CE') AS ce_tick_atm,
+					anyIf(toNullable(ltp), option_type = 'PE')        AS pe_tick,
+					anyIf(toNullable(strike), option_type = 'PE')     AS pe_tick_strike,
+					anyIf(toNullable(atm_strike), option_type = 'PE') AS pe_tick_atm,
+					any(spot_price)                                   AS spot_price
+				FROM options_moneyness
+				WHERE underlying = ?
+				  AND ` + legSQL + `
+				  AND ts >= ?
+				  AND ts < ?
+				  AND toString(expiry) = arrayElement(?, indexOf(?, toYYYYMMDD(ts, '` + tz + `')))
+				GROUP BY ts
+			)
+			WINDOW legs AS (
+				PARTITION BY toDate(ts, '` + tz + `')
+				ORDER BY ts
+				ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+			)
+		)
+		WHERE ce_last IS NOT NULL
+		  AND pe_last IS NOT NULL` + sameRoll + `
+	)`
+
+	args := []any{underlying}
+	args = append(args, legArgs...)
+	args = append(args, start, to, expiries, days)
+
+	return table, args, true, nil
+}
+
+// GetStraddle returns the combined CE + PE premium of a straddle or
+// strangle on the expiry rule resolved per session: per-second rows when
+// spec is nil, bars of the premium otherwise.
+func GetStraddle(
+	underlying string,
+	legs StraddleLegs,
+	expiryRule ExpiryRule,
+	from time.Time,
+	to time.Time,
+	spec *ResampleSpec,
+) (any, error) {
+
+	cal := calendar.For(underlying)
+
+	table, tableArgs, ok, err := straddleTable(underlying, legs, expiryRule, cal, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec != nil {
+		if !ok {
+			return models.ColumnarOHLC{
+				Ts:    []time.Time{},
+				Open:  []float64{},
+				High:  []float64{},
+				Low:   []float64{},
+				Close: []float64{},
+			}, nil
+		}
+		return buildBars(
+			resampleSource{
+				Table:     table,
+				TableArgs: tableArgs,
+				Price:     "premium",
+				Filter:    "1",
+			},
+			cal,
+			from,
+			to,
+			*spec,
+		)
+	}
+
+	out := models.StraddleColumnar{
+		Ts:       []time.Time{},
+		Premium:  []float64{},
+		CeLtp:    []float64{},
+		PeLtp:    []float64{},
+		CeStrike: []uint32{},
+		PeStrike: []uint32{},
+		Spot:     []float64{},
+	}
+
+	if !ok {
+		return out, nil
+	}
+
+	query := `
+		SELECT
+			ts,
+			premium,
+			ce_ltp,
+			pe_ltp,
+			ce_strike,
+			pe_strike,
+			spot_price
+		FROM ` + table + `
+		WHERE ts >= ?
+		  AND ts < ?
+		ORDER BY ts
+	`
+
+	args := append([]any{}, tableArgs...)
+	args = append(args, from, to)
+
+	rows, err := services.GetClickHouse().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts time.Time
+		var premium, ce, pe, spot float64
+		var ceStrike, peStrike uint32
+
+		if err := rows.Scan(&ts, &premium, &ce, &pe, &ceStrike, &peStrike, &spot); err != nil {
+			return nil, err
+		}
+
+		out.Ts = append(out.Ts, ts)
+		out.Premium = append(out.Premium, premium)
+		out.CeLtp = append(out.CeLtp, ce)
+		out.PeLtp = append(out.PeLtp, pe)
+		out.CeStrike = append(out.CeStrike, ceStrike)
+		out.PeStrike = append(out.PeStrike, peStrike)
+		out.Spot = append(out.Spot, spot)
+	}
+
+	return out, rows.Err()
+}
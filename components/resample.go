@@ -0,0 +1,542 @@
+package components
+
+import (
+	"math"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// Gap-fill policies for buckets without ticks.
+const (
+	FillNone    = "none"  // drop the bucket
+	FillForward = "ffill" // flat candle at the previous close
+	FillNull    = "null"  // emit the bucket with null OHLC
+)
+
+// Candle label, bucket closure and partial-bucket policies.
+const (
+	LabelStart = "start" // candle stamped with its bucket start
+	LabelEnd   = "end"   // candle stamped with its bucket end
+
+	ClosedLeft  = "left"  // [start, end): a tick on a boundary opens the next bucket
+	ClosedRight = "right" // (start, end]: a tick on a boundary closes the previous bucket
+
+	PartialDrop    = "drop"    // drop partial buckets (v1 behaviour)
+	PartialInclude = "include" // keep partial buckets
+	PartialFlag    = "flag"    // keep them and mark them in the partial column
+)
+
+// Optional per-bucket aggregations, returned alongside OHLC.
+const (
+	AggCount   = "count"    // ticks in the bucket
+	AggMean    = "mean"     // arithmetic mean
+	AggTwap    = "twap"     // time-weighted average, each tick held until the next
+	AggStddev  = "stddev"   // population standard deviation
+	AggFirstTs = "first_ts" // timestamp of the first tick
+	AggLastTs  = "last_ts"  // timestamp of the last tick
+)
+
+var aggSQL = map[string]string{
+	AggCount:   "count()",
+	AggMean:    "avg(price)",
+	AggTwap:    "sum(price * tick_hold) / sum(tick_hold)",
+	AggStddev:  "stddevPop(price)",
+	AggFirstTs: "min(tick_ts)",
+	AggLastTs:  "max(tick_ts)",
+}
+
+// ResampleSpec is everything that shapes resampled candles.
+type ResampleSpec struct {
+	Tf      Timeframe
+	Offset  int64
+	Fill    string
+	Label   string
+	Closed  string
+	Partial string
+	Aggs    []string
+
+	Bars       string  // time (default) | heikin_ashi | renko | range | tick
+	BarSize    float64 // renko brick, range size or ticks per bar
+	BarPercent bool    // renko brick is a percentage of price
+}
+
+func (spec ResampleSpec) hasAgg(agg string) bool {
+	for _, a := range spec.Aggs {
+		if a == agg {
+			return true
+		}
+	}
+	return false
+}
+
+// resampleSource describes where the prices being resampled live.
+type resampleSource struct {
+	Table     string // table, or a subquery exposing ts and the price inputs
+	TableArgs []any  // args for a subquery Table
+	Price     string // price column or expression
+	PriceArgs []any  // args for Price
+	Filter    string // extra WHERE conditions, ANDed with the time range
+	Args      []any  // args for Filter
+}
+
+// sessionWindow is one session clamped to the requested range.
+type sessionWindow struct {
+	calendar.Session
+	EffectiveStart time.Time
+	EffectiveEnd   time.Time
+}
+
+// Clamped reports whether the requested range cuts into the session.
+func (w sessionWindow) Clamped() bool {
+	return !w.EffectiveStart.Equal(w.Open) || !w.EffectiveEnd.Equal(w.Close)
+}
+
+// gridBucket is one candle slot the range should produce.
+type gridBucket struct {
+	Start   int64
+	End     int64
+	Partial bool // the range or the session boundaries cut into it
+	Opening bool // it starts within the first tf of the session
+}
+
+// dropped reports whether the partial mode leaves the bucket out. Under
+// drop (the v1 default) opening buckets go too: that is the v1
+// convention, since the opening bucket carries the pre-open auction
+// print.
+func (g gridBucket) dropped(mode string) bool {
+	if mode != "" && mode != PartialDrop {
+		return false
+	}
+	return g.Partial || g.Opening
+}
+
+// bucketing is the session lookup and bucket expression shared by every
+// session-aligned aggregation over a range. Queries select Columns from
+// the tick table, filter on Where and group by bucket_ts; the lookup
+// arrays are bound by With, ahead of any other argument.
+type bucketing struct {
+	Windows []sessionWindow
+	Periods []periodBucket
+
+	With      string // WITH clause binding the per-session arrays
+	Args      []any  // args for With
+	Columns   string // session_idx, session_start and bucket_ts of a tick
+	Where     string // range and session filter, args from RangeArgs
+	BucketEnd string // where a bucket's last tick stops being held
+}
+
+// newBucketing prepares the bucketing of [from, to). ok is false when no
+// session overlaps the range.
+func newBucketing(cal *calendar.Calendar, from, to time.Time, spec ResampleSpec) (bucketing, bool) {
+	windows := sessionWindows(cal, from, to)
+	if len(windows) == 0 {
+		return bucketing{}, false
+	}
+
+	// per-session lookup arrays, indexed by session_idx (1-based in SQL)
+	days := make([]uint32, 0, len(windows))
+	starts := make([]int64, 0, len(windows))
+	effectiveStarts := make([]int64, 0, len(windows))
+	effectiveEnds := make([]int64, 0, len(windows))
+
+	for _, w := range windows {
+		days = append(days, yyyymmdd(w.Date))
+		starts = append(starts, w.Open.Unix())
+		effectiveStarts = append(effectiveStarts, w.EffectiveStart.Unix())
+		effectiveEnds = append(effectiveEnds, w.EffectiveEnd.Unix())
+	}
+
+	periods := sessionBuckets(cal, windows, spec.Tf)
+	labels := make([]int64, len(periods))
+	for i, pb := range periods {
+		labels[i] = pb.Start
+	}
+
+	// intraday: offset-anchored buckets inside the session; right-closed
+	// buckets pull boundary ticks back by one second
+	bucketSQL := `session_start
+			+ offset_seconds
+			+ toInt64(floor(
+				(toInt64(toUnixTimestamp(ts))
+				- closed_shift
+				- session_start
+				- offset_seconds) / tf_seconds
+			)) * tf_seconds`
+
+	bucketEndSQL := "least(bucket_ts + tf_seconds + closed_shift, effective_ends[session_idx])"
+
+	// daily and longer: every session rolls into its period's candle
+	if !spec.Tf.Intraday() {
+		bucketSQL = "session_buckets[session_idx]"
+		bucketEndSQL = "effective_ends[session_idx]"
+	}
+
+	return bucketing{
+		Windows: windows,
+		Periods: periods,
+
+		With: `
+	WITH
+		? AS session_days,
+		? AS session_starts,
+		? AS effective_starts,
+		? AS effective_ends,
+		? AS session_buckets,
+		? AS tf_seconds,
+		? AS offset_seconds,
+		? AS closed_shift`,
+		Args: []any{
+			days,
+			starts,
+			effectiveStarts,
+			effectiveEnds,
+			labels,
+			spec.Tf.Seconds(),
+			spec.Offset,
+			closedShift(spec.Closed),
+		},
+
+		Columns: `indexOf(session_days, toYYYYMMDD(ts, '` + cal.Location().String() + `')) AS session_idx,
+			session_starts[session_idx] AS session_start,
+			toInt64(` + bucketSQL + `) AS bucket_ts`,
+
+		Where: `ts >= ?
+		  AND ts < ?
+		  AND session_idx > 0
+		  AND toInt64(toUnixTimestamp(ts)) >= effective_starts[session_idx]
+		  AND toInt64(toUnixTimestamp(ts)) < effective_ends[session_idx]`,
+
+		BucketEnd: bucketEndSQL,
+	}, true
+}
+
+// RangeArgs are the args of Where.
+func (b bucketing) RangeArgs() []any {
+	return []any{b.Windows[0].EffectiveStart, b.Windows[len(b.Windows)-1].EffectiveEnd}
+}
+
+// resampleOHLC buckets a price column into session-aligned candles in a
+// single query. Every tick is matched to its trading session by date.
+// Intraday buckets are anchored at that session's open plus the offset;
+// daily and longer candles group whole sessions.
+func resampleOHLC(
+	src resampleSource,
+	cal *calendar.Calendar,
+	from time.Time,
+	to time.Time,
+	spec ResampleSpec,
+) (models.ColumnarOHLC, error) {
+
+	out := models.ColumnarOHLC{
+		Ts:    []time.Time{},
+		Open:  []float64{},
+		High:  []float64{},
+		Low:   []float64{},
+		Close: []float64{},
+	}
+
+	b, ok := newBucketing(cal, from, to, spec)
+	if !ok {
+		return out, nil
+	}
+
+	aggSelect := ""
+	for _, a := range spec.Aggs {
+		aggSelect += ",\n\t\t" + aggSQL[a] + " AS agg_" + a
+	}
+
+	holdSelect := ""
+	if spec.hasAgg(AggTwap) {
+		holdSelect = `,
+			if(next_tick = 0, ` + b.BucketEnd + `, next_tick)
+			- toInt64(toUnixTimestamp(ts)) AS tick_hold,
+			leadInFrame(toInt64(toUnixTimestamp(ts))) OVER (
+				PARTITION BY session_idx, bucket_ts
+				ORDER BY ts
+				ROWS BETWEEN CURRENT ROW AND 1 FOLLOWING
+			) AS next_tick`
+	}
+
+	query := b.With + `
+	SELECT
+		bucket_ts,
+		argMin(price, tick_ts) AS open,
+		max(price)             AS high,
+		min(price)             AS low,
+		argMax(price, tick_ts) AS close` + aggSelect + `
+	FROM
+	(
+		SELECT
+			ts AS tick_ts,
+			` + src.Price + ` AS price,
+			` + b.Columns + holdSelect + `
+		FROM ` + src.Table + `
+		WHERE ` + src.Filter + `
+		  AND ` + b.Where + `
+	)
+	GROUP BY bucket_ts
+	ORDER BY bucket_ts
+	`
+
+	args := append([]any{}, b.Args...)
+	args = append(args, src.PriceArgs...)
+	args = append(args, src.TableArgs...)
+	args = append(args, src.Args...)
+	args = append(args, b.RangeArgs()...)
+
+	rows, err := services.GetClickHouse().Query(query, args...)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	type candle struct {
+		Open, High, Low, Close float64
+
+		Count              uint64
+		Mean, Twap, Stddev float64
+		FirstTs, LastTs    time.Time
+	}
+
+	candles := map[int64]candle{}
+
+	for rows.Next() {
+		var bucket int64
+		var c candle
+
+		dest := []any{&bucket, &c.Open, &c.High, &c.Low, &c.Close}
+		for _, a := range spec.Aggs {
+			switch a {
+			case AggCount:
+				dest = append(dest, &c.Count)
+			case AggMean:
+				dest = append(dest, &c.Mean)
+			case AggTwap:
+				dest = append(dest, &c.Twap)
+			case AggStddev:
+				dest = append(dest, &c.Stddev)
+			case AggFirstTs:
+				dest = append(dest, &c.FirstTs)
+			case AggLastTs:
+				dest = append(dest, &c.LastTs)
+			}
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return out, err
+		}
+
+		candles[bucket] = c
+	}
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+
+	fill := spec.Fill
+	if fill == "" {
+		fill = FillNone
+	}
+
+	if fill != FillNone {
+		out.Filled = []bool{}
+	}
+	if spec.Partial == PartialFlag {
+		out.Partial = []bool{}
+	}
+	if spec.hasAgg(AggCount) {
+		out.Count = []uint64{}
+	}
+	if spec.hasAgg(AggMean) {
+		out.Mean = []float64{}
+	}
+	if spec.hasAgg(AggTwap) {
+		out.Twap = []float64{}
+	}
+	if spec.hasAgg(AggStddev) {
+		out.Stddev = []float64{}
+	}
+	if spec.hasAgg(AggFirstTs) {
+		out.FirstTs = []time.Time{}
+	}
+	if spec.hasAgg(AggLastTs) {
+		out.LastTs = []time.Time{}
+	}
+
+	loc := cal.Location()
+	prevClose := math.NaN()
+
+	for _, g := range bucketGrid(b.Windows, b.Periods, spec) {
+		if g.dropped(spec.Partial) {
+			continue
+		}
+
+		c, ok := candles[g.Start]
+
+		if !ok {
+			switch fill {
+			case FillForward:
+				// nothing to carry forward before the first real candle
+				if math.IsNaN(prevClose) {
+					continue
+				}
+				c = candle{
+					Open:  prevClose,
+					High:  prevClose,
+					Low:   prevClose,
+					Close: prevClose,
+					Mean:  prevClose,
+					Twap:  prevClose,
+				}
+			case FillNull:
+				nan := math.NaN()
+				c = candle{
+					Open:   nan,
+					High:   nan,
+					Low:    nan,
+					Close:  nan,
+					Mean:   nan,
+					Twap:   nan,
+					Stddev: nan,
+				}
+			default:
+				continue
+			}
+		} else {
+			prevClose = c.Close
+		}
+
+		label := g.Start
+		if spec.Label == LabelEnd {
+			label = g.End
+		}
+
+		out.Ts = append(out.Ts, time.Unix(label, 0).In(loc))
+		out.Open = append(out.Open, c.Open)
+		out.High = append(out.High, c.High)
+		out.Low = append(out.Low, c.Low)
+		out.Close = append(out.Close, c.Close)
+
+		if out.Count != nil {
+			out.Count = append(out.Count, c.Count)
+		}
+		if out.Mean != nil {
+			out.Mean = append(out.Mean, c.Mean)
+		}
+		if out.Twap != nil {
+			out.Twap = append(out.Twap, c.Twap)
+		}
+		if out.Stddev != nil {
+			out.Stddev = append(out.Stddev, c.Stddev)
+		}
+		if out.FirstTs != nil {
+			out.FirstTs = append(out.FirstTs, c.FirstTs)
+		}
+		if out.LastTs != nil {
+			out.LastTs = append(out.LastTs, c.LastTs)
+		}
+
+		if out.Filled != nil {
+			out.Filled = append(out.Filled, !ok)
+		}
+		if out.Partial != nil {
+			out.Partial = append(out.Partial, g.Partial)
+		}
+	}
+
+	return out, nil
+}
+
+// sessionWindows returns the trading sessions overlapping [from, to),
+// each clamped to that range.
+func sessionWindows(cal *calendar.Calendar, from, to time.Time) []sessionWindow {
+	sessions, _ := cal.Sessions(from, to)
+
+	windows := make([]sessionWindow, 0, len(sessions))
+	for _, s := range sessions {
+		effectiveStart, effectiveEnd, ok := s.Clamp(from, to)
+		if !ok {
+			continue
+		}
+		windows = append(windows, sessionWindow{
+			Session:        s,
+			EffectiveStart: effectiveStart,
+			EffectiveEnd:   effectiveEnd,
+		})
+	}
+
+	return windows
+}
+
+// bucketGrid lists, in order, every bucket the range should produce,
+// whether or not it saw ticks.
+//
+// An intraday bucket is partial when the requested range or the session
+// boundaries cut into it. Buckets starting within the first tf of the
+// session are marked as opening buckets, which partial=drop leaves out
+// with the partial ones (see dropped).
+func bucketGrid(windows []sessionWindow, periods []periodBucket, spec ResampleSpec) []gridBucket {
+	grid := []gridBucket{}
+
+	if !spec.Tf.Intraday() {
+		for i, pb := range periods {
+			if i == 0 || pb.Start != periods[i-1].Start {
+				grid = append(grid, gridBucket{
+					Start:   pb.Start,
+					End:     pb.End,
+					Partial: pb.Partial,
+				})
+			}
+		}
+		return grid
+	}
+
+	tf := spec.Tf.Seconds()
+	shift := closedShift(spec.Closed)
+
+	for _, w := range windows {
+		open := w.Open.Unix()
+		anchor := open + spec.Offset
+		effectiveStart := w.EffectiveStart.Unix()
+		effectiveEnd := w.EffectiveEnd.Unix()
+
+		// buckets holding the first and last tick second of the window
+		first := floorDiv(effectiveStart-shift-anchor, tf)
+		last := floorDiv(effectiveEnd-1-shift-anchor, tf)
+
+		for k := first; k <= last; k++ {
+			start := anchor + k*tf
+			end := start + tf
+
+			grid = append(grid, gridBucket{
+				Start:   start,
+				End:     end,
+				Partial: start < effectiveStart || end > effectiveEnd,
+				Opening: start < open+tf,
+			})
+		}
+	}
+
+	return grid
+}
+
+func closedShift(closed string) int64 {
+	if closed == ClosedRight {
+		return 1
+	}
+	return 0
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func yyyymmdd(t time.Time) uint32 {
+	return uint32(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
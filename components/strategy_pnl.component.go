@@ -0,0 +1,323 @@
+package components
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/models"
+	"quant-read-api/services"
+)
+
+// Strategy leg instruments, sides and statuses.
+const (
+	InstrumentCall    = "CE"
+	InstrumentPut     = "PE"
+	InstrumentFutures = "FUT"
+
+	SideBuy  = "buy"
+	SideSell = "sell"
+
+	LegOK        = "ok"
+	LegNotListed = "not_listed" // expiry or strike did not resolve at entry
+	LegNoPrints  = "no_prints"  // nothing traded between entry and exit
+)
+
+// StrategyLeg is one position of a strategy, held from Entry to Exit.
+type StrategyLeg struct {
+	Underlying   string
+	Instrument   string     // CE | PE | FUT
+	Expiry       ExpiryRule // options: resolved on the entry session
+	Series       string     // futures: near | next | far
+	Strike       uint32
+	StrikeOffset *int // strikes above ATM at entry, instead of Strike
+	Quantity     int  // units, not lots
+	Side         string
+	Entry        time.Time
+	Exit         time.Time
+}
+
+// sign is +1 for a long leg and -1 for a short one.
+func (l StrategyLeg) sign() float64 {
+	if l.Side == SideSell {
+		return -1
+	}
+	return 1
+}
+
+// legSeries is the marks of one leg between its fills.
+type legSeries struct {
+	Ts    []time.Time
+	Price []float64
+
+	EntryTs, ExitTs       time.Time
+	EntryPrice, ExitPrice float64
+}
+
+// GetStrategyPnL marks every leg to market on the union of the legs'
+// timestamps, per second or per bucket. A leg fills at the first print at
+// or after its entry and exits at the last print before its exit; before
+// the fill it contributes nothing, after the exit its realised P&L. The
+// net premium is that of the open option legs, sells positive.
+func GetStrategyPnL(legs []StrategyLeg, spec *ResampleSpec) (models.StrategyPnL, error) {
+	out := models.StrategyPnL{
+		Ts:         []time.Time{},
+		Pnl:        models.FloatColumn{},
+		NetPremium: models.FloatColumn{},
+		Legs:       make([]models.StrategyLegPnL, len(legs)),
+	}
+
+	var legSpec *ResampleSpec
+	if spec != nil {
+		// every leg is bucketed on the same grid; the buckets an entry or
+		// exit cuts into are kept, and first_ts/last_ts give the fills
+		s := *spec
+		s.Bars = BarsTime
+		s.Fill = FillNone
+		s.Partial = PartialInclude
+		s.Aggs = []string{AggFirstTs, AggLastTs}
+		legSpec = &s
+	}
+
+	series := make([]*legSeries, len(legs))
+	stamps := map[int64]time.Time{}
+
+	for i, leg := range legs {
+		l := &out.Legs[i]
+		*l = models.StrategyLegPnL{
+			Leg:        i + 1,
+			Underlying: leg.Underlying,
+			Instrument: leg.Instrument,
+			Side:       leg.Side,
+			Quantity:   leg.Quantity,
+			Status:     LegOK,
+			Price:      models.FloatColumn{},
+			Pnl:        models.FloatColumn{},
+		}
+
+		s, err := loadLeg(leg, l, legSpec)
+		if err != nil {
+			return out, err
+		}
+		if s == nil {
+			continue
+		}
+
+		l.EntryTs = s.EntryTs.Format(time.RFC3339)
+		l.EntryPrice = optionalFloat(s.EntryPrice)
+		l.ExitTs = s.ExitTs.Format(time.RFC3339)
+		l.ExitPrice = optionalFloat(s.ExitPrice)
+
+		series[i] = s
+		for _, ts := range s.Ts {
+			stamps[ts.Unix()] = ts
+		}
+	}
+
+	keys := make([]int64, 0, len(stamps))
+	for k := range stamps {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+
+	next := make([]int, len(legs)) // per leg, marks at or before the current ts
+
+	for _, k := range keys {
+		ts := stamps[k]
+		pnl, premium := 0.0, 0.0
+
+		for i, leg := range legs {
+			l := &out.Legs[i]
+			s := series[i]
+
+			price, legPnl := math.NaN(), math.NaN()
+
+			if s != nil {
+				for next[i] < len(s.Ts) && s.Ts[next[i]].Unix() <= k {
+					next[i]++
+				}
+
+				switch {
+				case next[i] == 0:
+					legPnl = 0
+				case ts.Before(leg.Exit):
+					price = s.Price[next[i]-1]
+					legPnl = leg.sign() * float64(leg.Quantity) * (price - s.EntryPrice)
+				default:
+					legPnl = leg.sign() * float64(leg.Quantity) * (s.ExitPrice - s.EntryPrice)
+				}
+			}
+
+			l.Price = append(l.Price, price)
+			l.Pnl = append(l.Pnl, legPnl)
+
+			pnl += legPnl
+
+			if leg.Instrument == InstrumentFutures {
+				continue
+			}
+			switch {
+			case s == nil:
+				premium = math.NaN()
+			case !math.IsNaN(price):
+				premium -= leg.sign() * float64(leg.Quantity) * price
+			}
+		}
+
+		out.Ts = append(out.Ts, ts)
+		out.Pnl = append(out.Pnl, pnl)
+		out.NetPremium = append(out.NetPremium, premium)
+	}
+
+	return out, nil
+}
+
+// loadLeg resolves a leg's contract into l and reads its marks between
+// entry and exit. It returns nil, with l.Status set, when the leg has
+// nothing to mark.
+func loadLeg(leg StrategyLeg, l *models.StrategyLegPnL, spec *ResampleSpec) (*legSeries, error) {
+	cal := calendar.For(leg.Underlying)
+
+	var data any
+	var err error
+
+	if leg.Instrument == InstrumentFutures {
+		l.Series = leg.Series
+
+		data, err = GetFuturesData(leg.Underlying, leg.Series, leg.Entry, leg.Exit, spec)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		session, ok := sessionAsOf(cal, leg.Entry)
+		if !ok {
+			l.Status = LegNotListed
+			return nil, nil
+		}
+
+		expiries, err := sessionExpiries(leg.Underlying, []calendar.Session{session}, leg.Expiry)
+		if err != nil {
+			return nil, err
+		}
+
+		l.Expiry = expiries[0]
+		if l.Expiry == "" {
+			l.Status = LegNotListed
+			return nil, nil
+		}
+
+		l.Strike = leg.Strike
+		if leg.StrikeOffset != nil {
+			l.Strike, err = atmOffsetStrike(leg, l.Expiry, session)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if l.Strike == 0 {
+			l.Status = LegNotListed
+			return nil, nil
+		}
+
+		data, err = GetOptionContract(leg.Underlying, ExpiryOn(l.Expiry), l.Strike, leg.Instrument, leg.Entry, leg.Exit, spec, nil, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := &legSeries{}
+
+	switch v := data.(type) {
+	case []models.OptionContractRow:
+		for _, r := range v {
+			s.Ts = append(s.Ts, r.Ts)
+			s.Price = append(s.Price, r.Ltp)
+		}
+	case []models.FuturesDataRow:
+		for _, r := range v {
+			s.Ts = append(s.Ts, r.Ts)
+			s.Price = append(s.Price, r.FuturesPrice)
+		}
+	case models.ColumnarOHLC:
+		if len(v.Ts) > 0 {
+			s.Ts = v.Ts
+			s.Price = v.Close
+			s.EntryTs, s.EntryPrice = v.FirstTs[0], v.Open[0]
+			s.ExitTs, s.ExitPrice = v.LastTs[len(v.Ts)-1], v.Close[len(v.Ts)-1]
+		}
+	}
+
+	if len(s.Ts) == 0 {
+		l.Status = LegNoPrints
+		return nil, nil
+	}
+
+	if spec == nil {
+		n := len(s.Ts)
+		s.EntryTs, s.EntryPrice = s.Ts[0], s.Price[0]
+		s.ExitTs, s.ExitPrice = s.Ts[n-1], s.Price[n-1]
+	}
+
+	return s, nil
+}
+
+// atmOffsetStrike resolves a strike offset from ATM to the strike on the
+// matching moneyness slice at entry.
+func atmOffsetStrike(leg StrategyLeg, expiry string, session calendar.Session) (uint32, error) {
+	offset := *leg.StrikeOffset
+
+	moneyness, lvl := "ATM", 0
+	switch {
+	case offset == 0:
+	case (offset > 0) == (leg.Instrument == InstrumentCall):
+		// strikes above ATM are out of the money for calls, in for puts
+		moneyness, lvl = "OTM", max(offset, -offset)
+	default:
+		moneyness, lvl = "ITM", max(offset, -offset)
+	}
+
+	return sliceStrike(leg.Underlying, expiry, leg.Instrument, moneyness, lvl, session, leg.Entry, leg.Exit)
+}
+
+// sliceStrike returns the strike on a moneyness slice at the latest print
+// at or before at in its session, or at the first print after at when
+// nothing traded before it. It returns 0 when the slice never printed
+// before until.
+func sliceStrike(
+	underlying string,
+	expiry string,
+	optionType string,
+	moneyness string,
+	moneynessLvl int,
+	session calendar.Session,
+	at time.Time,
+	until time.Time,
+) (uint32, error) {
+
+	moneynessSQL, moneynessArgs := moneynessFilter(moneyness, moneynessLvl)
+
+	query := `
+		SELECT
+			if(
+				countIf(ts <= ?) > 0,
+				argMaxIf(strike, ts, ts <= ?),
+				argMinIf(strike, ts, ts > ?)
+			)
+		FROM options_moneyness
+		WHERE underlying = ?
+		  AND expiry = toDate(?)
+		  AND option_type = ?
+		  AND ` + moneynessSQL + `
+		  AND ts >= ?
+		  AND ts < ?
+	`
+
+	args := []any{at, at, at, underlying, expiry, optionType}
+	args = append(args, moneynessArgs...)
+	args = append(args, session.Open, until)
+
+	var strike uint32
+	err := services.GetClickHouse().QueryRow(query, args...).Scan(&strike)
+
+	return strike, err
+}
@@ -0,0 +1,188 @@
+package components
+
+import (
+	"fmt"
+	"time"
+
+	"quant-read-api/calendar"
+)
+
+// Timeframe is a parsed tf parameter such as 5s, 75m, 1h, 1d, 1w or 1M.
+//
+// Intraday units (s, m, h) are bucketed inside each session, anchored at
+// the session open plus the requested offset. Session units (d, w, M) roll
+// whole trading sessions up into one candle.
+type Timeframe struct {
+	Value int64
+	Unit  byte
+}
+
+func (tf Timeframe) String() string {
+	return fmt.Sprintf("%d%c", tf.Value, tf.Unit)
+}
+
+// Intraday reports whether candles are cut inside a session.
+func (tf Timeframe) Intraday() bool {
+	switch tf.Unit {
+	case 's', 'm', 'h':
+		return true
+	}
+	return false
+}
+
+// Seconds is the bucket width of an intraday timeframe, 0 otherwise.
+func (tf Timeframe) Seconds() int64 {
+	switch tf.Unit {
+	case 's':
+		return tf.Value
+	case 'm':
+		return tf.Value * 60
+	case 'h':
+		return tf.Value * 3600
+	}
+	return 0
+}
+
+// periodBucket is the daily-or-longer candle a session rolls up into.
+type periodBucket struct {
+	Start   int64 // open of the period's first trading session
+	End     int64 // close of the period's last trading session
+	Partial bool  // the requested range covers only part of the period
+}
+
+// sessionBuckets assigns every session to the candle it rolls up into.
+// Periods are bounded by the calendar, not by the requested range, so
+// candles line up regardless of from.
+func sessionBuckets(cal *calendar.Calendar, sessions []sessionWindow, tf Timeframe) []periodBucket {
+	buckets := make([]periodBucket, len(sessions))
+
+	if tf.Unit == 'd' {
+		return dayBuckets(cal, sessions, int(tf.Value))
+	}
+
+	periods := map[string]*periodBucket{}
+	tradingDays := map[string]int{}
+	covered := map[string]int{}
+	keys := make([]string, len(sessions))
+
+	for i, w := range sessions {
+		start, end := periodBounds(w.Date, tf)
+		key := start.Format("2006-01-02")
+		keys[i] = key
+
+		pb, ok := periods[key]
+		if !ok {
+			pb = &periodBucket{Start: w.Open.Unix(), End: w.Close.Unix()}
+			if inPeriod, _ := cal.Sessions(start, end); len(inPeriod) > 0 {
+				pb.Start = inPeriod[0].Open.Unix()
+				pb.End = inPeriod[len(inPeriod)-1].Close.Unix()
+				tradingDays[key] = len(inPeriod)
+			}
+			periods[key] = pb
+		}
+
+		pb.Partial = pb.Partial || w.Clamped()
+		covered[key]++
+	}
+
+	for key, pb := range periods {
+		if covered[key] < tradingDays[key] {
+			pb.Partial = true
+		}
+	}
+
+	for i, key := range keys {
+		buckets[i] = *periods[key]
+	}
+
+	return buckets
+}
+
+// dayBuckets groups sessions into blocks of n, counted from the first
+// session the calendar covers. Extending the coverage backwards moves
+// the blocks; moving from does not.
+func dayBuckets(cal *calendar.Calendar, sessions []sessionWindow, n int) []periodBucket {
+	buckets := make([]periodBucket, len(sessions))
+	if len(sessions) == 0 {
+		return buckets
+	}
+
+	// enough calendar days past the last session to close its block
+	last := sessions[len(sessions)-1].Date
+	all, _ := cal.Sessions(cal.CoverageStart(), last.AddDate(0, 0, 3*n+10))
+
+	index := make(map[string]int, len(all))
+	for i, s := range all {
+		index[s.Date.Format("2006-01-02")] = i
+	}
+
+	blocks := map[int]*periodBucket{}
+	covered := map[int]int{}
+	keys := make([]int, len(sessions))
+
+	for i, w := range sessions {
+		k, ok := index[w.Date.Format("2006-01-02")]
+		if !ok {
+			// not a calendar session; stands alone
+			buckets[i] = periodBucket{Start: w.Open.Unix(), End: w.Close.Unix(), Partial: true}
+			keys[i] = -1
+			continue
+		}
+
+		b := k - k%n
+		keys[i] = b
+
+		pb, ok := blocks[b]
+		if !ok {
+			block := all[b:min(b+n, len(all))]
+			pb = &periodBucket{
+				Start:   block[0].Open.Unix(),
+				End:     block[len(block)-1].Close.Unix(),
+				Partial: len(block) < n,
+			}
+			blocks[b] = pb
+		}
+
+		pb.Partial = pb.Partial || w.Clamped()
+		covered[b]++
+	}
+
+	for b, pb := range blocks {
+		if covered[b] < n {
+			pb.Partial = true
+		}
+	}
+
+	for i, b := range keys {
+		if b >= 0 {
+			buckets[i] = *blocks[b]
+		}
+	}
+
+	return buckets
+}
+
+// weekEpoch is a Monday, used to group weeks into N-week periods.
+var weekEpoch = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// periodBounds returns the [start, end) calendar dates of the weekly or
+// monthly period containing day.
+func periodBounds(day time.Time, tf Timeframe) (time.Time, time.Time) {
+	loc := day.Location()
+
+	switch tf.Unit {
+	case 'w':
+		utcDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+		weeks := int64(utcDay.Sub(weekEpoch).Hours()) / (24 * 7)
+		weeks -= weeks % tf.Value
+		start := weekEpoch.AddDate(0, 0, int(weeks*7))
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, int(7*tf.Value))
+
+	default: // 'M'
+		months := int64(day.Year())*12 + int64(day.Month()) - 1
+		months -= months % tf.Value
+		start := time.Date(int(months/12), time.Month(months%12+1), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, int(tf.Value), 0)
+	}
+}
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"quant-read-api/backtest"
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+// maxBacktestDays bounds the calendar days of one backtest.
+const maxBacktestDays = 366
+
+// backtestTrigger is a stop-loss or target in a backtest request.
+type backtestTrigger struct {
+	On        string  `json:"on"`
+	Points    float64 `json:"points"`
+	Percent   float64 `json:"percent"`
+	Direction string  `json:"direction"`
+}
+
+// backtestRequest is the JSON body of a backtest request.
+type backtestRequest struct {
+	Underlying string `json:"underlying"`
+	Expiry     string `json:"expiry"`
+	Nth        int    `json:"nth"`
+	DteMin     *int   `json:"dte_min"`
+	DteMax     *int   `json:"dte_max"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	EntryTime  string `json:"entry_time"`
+	ExitTime   string `json:"exit_time"`
+
+	Legs []struct {
+		OptionType   string  `json:"option_type"`
+		Side         string  `json:"side"`
+		Quantity     int     `json:"quantity"`
+		Moneyness    string  `json:"moneyness"`
+		MoneynessLvl int     `json:"moneyness_lvl"`
+		Premium      float64 `json:"premium"`
+	} `json:"legs"`
+
+	StopLoss *backtestTrigger `json:"stop_loss"`
+	Target   *backtestTrigger `json:"target"`
+}
+
+func RunBacktest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	var req backtestRequest
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "invalid backtest: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Underlying == "" || req.From == "" || req.To == "" ||
+		req.EntryTime == "" || req.ExitTime == "" {
+		http.Error(w, "missing underlying, from, to, entry_time or exit_time", http.StatusBadRequest)
+		return
+	}
+
+	expiryRule, err := newExpiryRule(req.Expiry, req.Nth, req.DteMin, req.DteMax, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", req.From, loc)
+	if err != nil {
+		http.Error(w, "invalid from date", http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.ParseInLocation("2006-01-02", req.To, loc)
+	if err != nil || to.Before(from) || to.Sub(from) >= maxBacktestDays*24*time.Hour {
+		http.Error(w, fmt.Sprintf("invalid to date (on or after from, within %d days)", maxBacktestDays), http.StatusBadRequest)
+		return
+	}
+
+	entryTime, err := parseClock(req.EntryTime)
+	if err != nil {
+		http.Error(w, "invalid entry_time (HH:MM:SS)", http.StatusBadRequest)
+		return
+	}
+
+	exitTime, err := parseClock(req.ExitTime)
+	if err != nil || exitTime <= entryTime {
+		http.Error(w, "invalid exit_time (HH:MM:SS, after entry_time)", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Legs) == 0 || len(req.Legs) > maxStrategyLegs {
+		http.Error(w, fmt.Sprintf("backtest needs 1 to %d legs", maxStrategyLegs), http.StatusBadRequest)
+		return
+	}
+
+	spec := components.BacktestSpec{
+		Underlying: req.Underlying,
+		Expiry:     expiryRule,
+		From:       from,
+		To:         to,
+		EntryTime:  entryTime,
+		ExitTime:   exitTime,
+		Legs:       make([]components.BacktestLeg, len(req.Legs)),
+	}
+
+	for i, l := range req.Legs {
+		bad := func(msg string) {
+			http.Error(w, fmt.Sprintf("leg %d: %s", i+1, msg), http.StatusBadRequest)
+		}
+
+		if l.OptionType != components.InstrumentCall && l.OptionType != components.InstrumentPut {
+			bad("invalid option_type (CE, PE)")
+			return
+		}
+
+		if l.Side != components.SideBuy && l.Side != components.SideSell {
+			bad("invalid side (buy, sell)")
+			return
+		}
+
+		if l.Quantity <= 0 {
+			bad("quantity must be positive")
+			return
+		}
+
+		if l.Moneyness == "" {
+			l.Moneyness = "ATM"
+		}
+
+		switch {
+		case l.Premium < 0:
+			bad("premium must be positive")
+			return
+		case l.Premium > 0 && (l.Moneyness != "ATM" || l.MoneynessLvl != 0):
+			bad("select by premium or by moneyness, not both")
+			return
+		case l.Moneyness == "ATM":
+			l.MoneynessLvl = 0
+		case l.Moneyness != "ITM" && l.Moneyness != "OTM":
+			bad("invalid moneyness (ATM, ITM, OTM)")
+			return
+		case l.MoneynessLvl < 1:
+			bad("moneyness_lvl must be 1 or more for ITM and OTM")
+			return
+		}
+
+		spec.Legs[i] = components.BacktestLeg{
+			OptionType:   l.OptionType,
+			Side:         l.Side,
+			Quantity:     l.Quantity,
+			Moneyness:    l.Moneyness,
+			MoneynessLvl: l.MoneynessLvl,
+			Premium:      l.Premium,
+		}
+	}
+
+	spec.Rules.StopLoss, err = parseTrigger(req.StopLoss)
+	if err != nil {
+		http.Error(w, "stop_loss: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spec.Rules.Target, err = parseTrigger(req.Target)
+	if err != nil {
+		http.Error(w, "target: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := components.GetBacktest(spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exchange := calendar.For(req.Underlying)
+	sessions, skipped := exchange.Sessions(from, to.AddDate(0, 0, 1))
+
+	meta := models.Meta{
+		Underlying: req.Underlying,
+		Exchange:   exchange.Exchange,
+		Expiry:     expiryRule.String(),
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parseClock reads a HH:MM:SS time of day as the offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04:05", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second, nil
+}
+
+// parseTrigger validates a stop-loss or target; nil stays nil.
+func parseTrigger(t *backtestTrigger) (*backtest.Trigger, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	if t.On != backtest.OnPremium && t.On != backtest.OnSpot {
+		return nil, fmt.Errorf("invalid on (premium, spot)")
+	}
+
+	if (t.Points > 0) == (t.Percent > 0) || t.Points < 0 || t.Percent < 0 {
+		return nil, fmt.Errorf("needs exactly one positive points or percent")
+	}
+
+	switch t.Direction {
+	case "":
+		t.Direction = backtest.DirectionEither
+	case backtest.DirectionUp, backtest.DirectionDown, backtest.DirectionEither:
+		if t.On != backtest.OnSpot {
+			return nil, fmt.Errorf("direction applies to spot triggers only")
+		}
+	default:
+		return nil, fmt.Errorf("invalid direction (up, down, either)")
+	}
+
+	return &backtest.Trigger{
+		On:        t.On,
+		Points:    t.Points,
+		Percent:   t.Percent,
+		Direction: t.Direction,
+	}, nil
+}
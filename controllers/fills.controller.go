@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"quant-read-api/components"
+	"quant-read-api/execution"
+	"quant-read-api/models"
+)
+
+// maxOrders bounds the orders of one fill simulation.
+const maxOrders = 500
+
+// defaultTickSize is the NSE F&O tick.
+const defaultTickSize = 0.05
+
+// fillsRequest is the JSON body of a fill simulation.
+type fillsRequest struct {
+	LatencyMs int64    `json:"latency_ms"`
+	TickSize  *float64 `json:"tick_size"`
+
+	Slippage struct {
+		Model string  `json:"model"`
+		Value float64 `json:"value"`
+	} `json:"slippage"`
+
+	// defaults to the NSE schedule; fields given here override it
+	Charges execution.Schedule `json:"charges"`
+
+	Orders []struct {
+		Underlying string  `json:"underlying"`
+		Instrument string  `json:"instrument"`
+		Expiry     string  `json:"expiry"`
+		Nth        int     `json:"nth"`
+		DteMin     *int    `json:"dte_min"`
+		DteMax     *int    `json:"dte_max"`
+		Strike     uint32  `json:"strike"`
+		Series     string  `json:"series"`
+		Ts         string  `json:"ts"`
+		Side       string  `json:"side"`
+		Quantity   int     `json:"quantity"`
+		Type       string  `json:"type"`
+		LimitPrice float64 `json:"limit_price"`
+		ValidUntil string  `json:"valid_until"`
+	} `json:"orders"`
+}
+
+func SimulateFills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	req := fillsRequest{Charges: execution.NSE}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "invalid orders: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Orders) == 0 || len(req.Orders) > maxOrders {
+		http.Error(w, fmt.Sprintf("needs 1 to %d orders", maxOrders), http.StatusBadRequest)
+		return
+	}
+
+	if req.LatencyMs < 0 {
+		http.Error(w, "invalid latency_ms", http.StatusBadRequest)
+		return
+	}
+
+	cfg := components.FillConfig{
+		Latency:  time.Duration(req.LatencyMs) * time.Millisecond,
+		Slippage: execution.Slippage{Model: req.Slippage.Model, Value: req.Slippage.Value},
+		TickSize: defaultTickSize,
+		Charges:  req.Charges,
+	}
+
+	if req.TickSize != nil {
+		if *req.TickSize < 0 {
+			http.Error(w, "invalid tick_size", http.StatusBadRequest)
+			return
+		}
+		cfg.TickSize = *req.TickSize
+	}
+
+	switch cfg.Slippage.Model {
+	case "":
+		cfg.Slippage.Model = execution.SlippageNone
+	case execution.SlippageNone, execution.SlippagePoints, execution.SlippageBps:
+	default:
+		http.Error(w, "invalid slippage model (none, points, bps)", http.StatusBadRequest)
+		return
+	}
+	if cfg.Slippage.Value < 0 {
+		http.Error(w, "invalid slippage value", http.StatusBadRequest)
+		return
+	}
+
+	orders := make([]components.Order, len(req.Orders))
+
+	for i, o := range req.Orders {
+		bad := func(msg string) {
+			http.Error(w, fmt.Sprintf("order %d: %s", i+1, msg), http.StatusBadRequest)
+		}
+
+		if o.Underlying == "" || o.Ts == "" {
+			bad("missing underlying or ts")
+			return
+		}
+
+		ts, err := time.ParseInLocation("2006-01-02T15:04:05", o.Ts, loc)
+		if err != nil {
+			bad("invalid ts")
+			return
+		}
+
+		if o.Side != components.SideBuy && o.Side != components.SideSell {
+			bad("invalid side (buy, sell)")
+			return
+		}
+
+		if o.Quantity <= 0 {
+			bad("quantity must be positive")
+			return
+		}
+
+		order := components.Order{
+			Underlying: o.Underlying,
+			Instrument: o.Instrument,
+			Ts:         ts,
+			Side:       o.Side,
+			Quantity:   o.Quantity,
+			Type:       o.Type,
+			LimitPrice: o.LimitPrice,
+		}
+
+		switch o.Instrument {
+		case components.InstrumentFutures:
+			order.Series = o.Series
+			if order.Series == "" {
+				order.Series = "near"
+			}
+
+		case components.InstrumentCall, components.InstrumentPut:
+			order.Expiry, err = newExpiryRule(o.Expiry, o.Nth, o.DteMin, o.DteMax, false)
+			if err != nil {
+				bad(err.Error())
+				return
+			}
+			if o.Strike == 0 {
+				bad("missing strike")
+				return
+			}
+			order.Strike = o.Strike
+
+		default:
+			bad("invalid instrument (CE, PE, FUT)")
+			return
+		}
+
+		switch o.Type {
+		case "":
+			order.Type = components.OrderMarket
+		case components.OrderMarket:
+		case components.OrderLimit:
+			if o.LimitPrice <= 0 {
+				bad("limit orders need a positive limit_price")
+				return
+			}
+		default:
+			bad("invalid type (market, limit)")
+			return
+		}
+
+		if o.ValidUntil != "" {
+			if order.Type != components.OrderLimit {
+				bad("valid_until applies to limit orders only")
+				return
+			}
+			order.ValidUntil, err = time.ParseInLocation("2006-01-02T15:04:05", o.ValidUntil, loc)
+			if err != nil || !order.ValidUntil.After(ts) {
+				bad("invalid valid_until (after ts)")
+				return
+			}
+		}
+
+		orders[i] = order
+	}
+
+	data, err := components.SimulateFills(orders, cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: models.Meta{},
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+func GetFuturesBasis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	series := q.Get("series")
+	fromStr := q.Get("from")
+	toStr := q.Get("to")
+
+	if underlying == "" || fromStr == "" || toStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	if series == "" {
+		series = "near"
+	}
+
+	from, err := time.ParseInLocation("2006-01-02T15:04:05", fromStr, loc)
+	if err != nil {
+		http.Error(w, "invalid from time", http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.ParseInLocation("2006-01-02T15:04:05", toStr, loc)
+	if err != nil {
+		http.Error(w, "invalid to time", http.StatusBadRequest)
+		return
+	}
+
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if spec != nil && (spec.Bars != components.BarsTime || len(spec.Aggs) > 0) {
+		http.Error(w, "basis supports time bars only, without aggs", http.StatusBadRequest)
+		return
+	}
+
+	if !calendar.HasFuturesExpiries(underlying) {
+		http.Error(w, "no futures expiry rule for "+underlying, http.StatusBadRequest)
+		return
+	}
+
+	data, err := components.GetFuturesBasis(
+		underlying,
+		series,
+		from,
+		to,
+		spec,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
+	var firstTs, lastTs string
+	if len(data.Ts) > 0 {
+		firstTs = data.Ts[0].Format(time.RFC3339)
+		lastTs = data.Ts[len(data.Ts)-1].Format(time.RFC3339)
+	}
+
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		Series:     series,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+		FirstTs:    firstTs,
+		LastTs:     lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	resampleMeta(&meta, spec)
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"quant-read-api/calendar"
 	"quant-read-api/components"
 	"quant-read-api/models"
 )
@@ -20,8 +21,6 @@ func GetFuturesData(w http.ResponseWriter, r *http.Request) {
 	series := q.Get("series")
 	fromStr := q.Get("from")
 	toStr := q.Get("to")
-	tfStr := q.Get("tf")
-	offsetStr := q.Get("offset")
 
 	if underlying == "" || fromStr == "" || toStr == "" {
 		http.Error(w, "missing query params", http.StatusBadRequest)
@@ -44,38 +43,90 @@ func GetFuturesData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var tfSeconds *int64
-	if tfStr != "" {
-		val, err := parseTF(tfStr)
-		if err != nil {
-			http.Error(w, "invalid tf", http.StatusBadRequest)
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	continuous := q.Get("continuous") == "1"
+
+	roll := components.RollRule{Mode: components.RollExpiry}
+	adjust := components.AdjustNone
+
+	if continuous {
+		if v := q.Get("roll"); v != "" {
+			roll.Mode = v
+		}
+
+		switch roll.Mode {
+		case components.RollExpiry:
+		case components.RollBefore:
+			roll.Days, err = strconv.Atoi(q.Get("roll_days"))
+			if err != nil || roll.Days <= 0 {
+				http.Error(w, "invalid roll_days", http.StatusBadRequest)
+				return
+			}
+		case components.RollCrossover:
+			http.Error(w, "roll=crossover needs traded volume or open interest, which futures_data does not carry", http.StatusBadRequest)
+			return
+		default:
+			http.Error(w, "invalid roll (expiry, before)", http.StatusBadRequest)
 			return
 		}
-		tfSeconds = &val
-	}
 
-	var offsetSeconds int64
-	if offsetStr != "" {
-		offsetSeconds, err = strconv.ParseInt(offsetStr, 10, 64)
-		if err != nil {
-			http.Error(w, "invalid offset", http.StatusBadRequest)
+		if _, err := components.NextSeries(series); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !calendar.HasFuturesExpiries(underlying) {
+			http.Error(w, "no futures expiry rule for "+underlying, http.StatusBadRequest)
+			return
+		}
+
+		if v := q.Get("adjust"); v != "" {
+			adjust = v
+		}
+
+		switch adjust {
+		case components.AdjustNone, components.AdjustDifference, components.AdjustRatio:
+		default:
+			http.Error(w, "invalid adjust (none, difference, ratio)", http.StatusBadRequest)
 			return
 		}
 	}
 
-	data, err := components.GetFuturesData(
-		underlying,
-		series,
-		from,
-		to,
-		tfSeconds,
-		offsetSeconds,
-	)
+	var data any
+	var rolls []models.FuturesRoll
+
+	if continuous {
+		data, rolls, err = components.GetContinuousFutures(
+			underlying,
+			series,
+			from,
+			to,
+			roll,
+			adjust,
+			spec,
+		)
+	} else {
+		data, err = components.GetFuturesData(
+			underlying,
+			series,
+			from,
+			to,
+			spec,
+		)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
 	var firstTs, lastTs string
 
 	switch v := data.(type) {
@@ -91,18 +142,29 @@ func GetFuturesData(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		Series:     series,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+		FirstTs:    firstTs,
+		LastTs:     lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	resampleMeta(&meta, spec)
+
+	if continuous {
+		meta.Roll = roll.Mode
+		meta.Adjust = adjust
+		meta.Rolls = rolls
+	}
+
 	resp := models.Response[any]{
 		Data: data,
-		Meta: models.Meta{
-			Underlying: underlying,
-			Series:     series,
-			From:       from.Format(time.RFC3339),
-			To:         to.Format(time.RFC3339),
-			Tf:         tfStr,
-			Offset:     offsetSeconds,
-			FirstTs:    firstTs,
-			LastTs:     lastTs,
-		},
+		Meta: meta,
 	}
 
 	json.NewEncoder(w).Encode(resp)
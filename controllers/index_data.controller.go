@@ -3,9 +3,9 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 	"time"
 
+	"quant-read-api/calendar"
 	"quant-read-api/components"
 	"quant-read-api/models"
 )
@@ -19,8 +19,6 @@ func GetIndexData(w http.ResponseWriter, r *http.Request) {
 	underlying := q.Get("underlying")
 	fromStr := q.Get("from")
 	toStr := q.Get("to")
-	tfStr := q.Get("tf")
-	offsetStr := q.Get("offset")
 
 	if underlying == "" || fromStr == "" || toStr == "" {
 		http.Error(w, "missing query params", http.StatusBadRequest)
@@ -39,37 +37,26 @@ func GetIndexData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var tfSeconds *int64
-	if tfStr != "" {
-		val, err := parseTF(tfStr)
-		if err != nil {
-			http.Error(w, "invalid tf", http.StatusBadRequest)
-			return
-		}
-		tfSeconds = &val
-	}
-
-	var offsetSeconds int64
-	if offsetStr != "" {
-		offsetSeconds, err = strconv.ParseInt(offsetStr, 10, 64)
-		if err != nil {
-			http.Error(w, "invalid offset", http.StatusBadRequest)
-			return
-		}
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	data, err := components.GetIndexData(
 		underlying,
 		from,
 		to,
-		tfSeconds,
-		offsetSeconds,
+		spec,
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
 	var firstTs, lastTs string
 
 	switch v := data.(type) {
@@ -85,17 +72,22 @@ func GetIndexData(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+		FirstTs:    firstTs,
+		LastTs:     lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	resampleMeta(&meta, spec)
+
 	resp := models.Response[any]{
 		Data: data,
-		Meta: models.Meta{
-			Underlying: underlying,
-			From:       from.Format(time.RFC3339),
-			To:         to.Format(time.RFC3339),
-			Tf:         tfStr,
-			Offset:     offsetSeconds,
-			FirstTs:    firstTs,
-			LastTs:     lastTs,
-		},
+		Meta: meta,
 	}
 
 	json.NewEncoder(w).Encode(resp)
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+// GetIVSmile returns the smile of one expiry, the nearest by default.
+func GetIVSmile(w http.ResponseWriter, r *http.Request) {
+	getIVSurface(w, r, false)
+}
+
+// GetIVSurface returns the smile of every expiry listed on or after at.
+func GetIVSurface(w http.ResponseWriter, r *http.Request) {
+	getIVSurface(w, r, true)
+}
+
+func getIVSurface(w http.ResponseWriter, r *http.Request, allExpiries bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	atStr := q.Get("at")
+
+	if underlying == "" || atStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	at, err := time.ParseInLocation("2006-01-02T15:04:05", atStr, loc)
+	if err != nil {
+		http.Error(w, "invalid at", http.StatusBadRequest)
+		return
+	}
+
+	market, err := parseCarry(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	grid, err := parseMoneynessGrid(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var expiry *time.Time
+	var expiryStr string
+
+	if !allExpiries {
+		e, ok := resolveExpiryAt(w, q, underlying, at)
+		if !ok {
+			return
+		}
+		expiryStr = e.Format("2006-01-02")
+		expiry = &e
+	}
+
+	data, err := components.GetIVSurface(
+		underlying,
+		expiry,
+		at,
+		market,
+		grid,
+	)
+	if errors.Is(err, components.ErrNoQuotes) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: models.Meta{
+			Underlying: underlying,
+			Exchange:   calendar.For(underlying).Exchange,
+			Expiry:     expiryStr,
+			At:         at.Format(time.RFC3339),
+		},
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
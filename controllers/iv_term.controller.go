@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+// maxTermRanks bounds how many expiries the term structure tracks.
+const maxTermRanks = 8
+
+func GetIVTermStructure(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	fromStr := q.Get("from")
+	toStr := q.Get("to")
+
+	if underlying == "" || fromStr == "" || toStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	from, err := time.ParseInLocation("2006-01-02T15:04:05", fromStr, loc)
+	if err != nil {
+		http.Error(w, "invalid from time", http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.ParseInLocation("2006-01-02T15:04:05", toStr, loc)
+	if err != nil {
+		http.Error(w, "invalid to time", http.StatusBadRequest)
+		return
+	}
+
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if spec != nil && (spec.Bars != components.BarsTime || len(spec.Aggs) > 0) {
+		http.Error(w, "term structure supports time bars only, without aggs", http.StatusBadRequest)
+		return
+	}
+
+	market, err := parseCarry(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ranks := 4
+	if v := q.Get("expiries"); v != "" {
+		ranks, err = strconv.Atoi(v)
+		if err != nil || ranks < 1 || ranks > maxTermRanks {
+			http.Error(w, "invalid expiries (1 to 8)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tenor := 30
+	if v := q.Get("tenor"); v != "" {
+		tenor, err = strconv.Atoi(v)
+		if err != nil || tenor < 1 || tenor > 365 {
+			http.Error(w, "invalid tenor (days, 1 to 365)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	data, err := components.GetIVTermStructure(
+		underlying,
+		from,
+		to,
+		spec,
+		market,
+		ranks,
+		tenor,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
+	var firstTs, lastTs string
+	if len(data.Ts) > 0 {
+		firstTs = data.Ts[0].Format(time.RFC3339)
+		lastTs = data.Ts[len(data.Ts)-1].Format(time.RFC3339)
+	}
+
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+		Tenor:      tenor,
+		FirstTs:    firstTs,
+		LastTs:     lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	resampleMeta(&meta, spec)
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
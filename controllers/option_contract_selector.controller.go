@@ -3,9 +3,13 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"quant-read-api/calendar"
 	"quant-read-api/components"
 	"quant-read-api/models"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -48,12 +52,18 @@ func GetOptionContractsByPremium(w http.ResponseWriter, r *http.Request) {
 
 	q := r.URL.Query()
 
+	switch q.Get("mode") {
+	case "", "search":
+	case "series":
+		getOptionPremiumSeries(w, q)
+		return
+	default:
+		http.Error(w, "invalid mode (search, series)", http.StatusBadRequest)
+		return
+	}
+
 	underlying := q.Get("underlying")
 	optionType := q.Get("option_type")
-	expiryMode := q.Get("expiry_mode")
-	if expiryMode == "" {
-		expiryMode = "nearest"
-	}
 
 	if underlying == "" || optionType == "" {
 		http.Error(w, "underlying and option_type are required", http.StatusBadRequest)
@@ -95,6 +105,18 @@ func GetOptionContractsByPremium(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expiryRule, err := parseExpiryRule(q, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	market, greeks, err := parseMarket(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var response interface{}
 
 	if optionType == "BOTH" {
@@ -104,10 +126,12 @@ func GetOptionContractsByPremium(w http.ResponseWriter, r *http.Request) {
 			"CE",
 			targetPremium,
 			tolerance,
-			expiryMode,
+			expiryRule,
 			from,
 			to,
 			50,
+			market,
+			greeks,
 		)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -119,10 +143,12 @@ func GetOptionContractsByPremium(w http.ResponseWriter, r *http.Request) {
 			"PE",
 			targetPremium,
 			tolerance,
-			expiryMode,
+			expiryRule,
 			from,
 			to,
 			50,
+			market,
+			greeks,
 		)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -141,10 +167,12 @@ func GetOptionContractsByPremium(w http.ResponseWriter, r *http.Request) {
 			optionType,
 			targetPremium,
 			tolerance,
-			expiryMode,
+			expiryRule,
 			from,
 			to,
 			50,
+			market,
+			greeks,
 		)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -159,3 +187,120 @@ func GetOptionContractsByPremium(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// getOptionPremiumSeries serves mode=series: the candles of the strike
+// closest to target_premium, re-selected per bucket or at reselect times.
+func getOptionPremiumSeries(w http.ResponseWriter, q url.Values) {
+	w.Header().Set("Content-Type", "application/json")
+
+	underlying := q.Get("underlying")
+	optionType := q.Get("option_type")
+	fromStr := q.Get("from")
+	toStr := q.Get("to")
+
+	if underlying == "" || fromStr == "" || toStr == "" {
+		http.Error(w, "underlying, from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	if optionType != components.InstrumentCall && optionType != components.InstrumentPut {
+		http.Error(w, "series needs option_type CE or PE", http.StatusBadRequest)
+		return
+	}
+
+	targetPremium, err := strconv.ParseFloat(q.Get("target_premium"), 64)
+	if err != nil || targetPremium <= 0 {
+		http.Error(w, "invalid target_premium", http.StatusBadRequest)
+		return
+	}
+
+	expiryRule, err := parseExpiryRule(q, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, fromStr)
+	if err != nil {
+		http.Error(w, "invalid from timestamp", http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.Parse(time.RFC3339, toStr)
+	if err != nil {
+		http.Error(w, "invalid to timestamp", http.StatusBadRequest)
+		return
+	}
+
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if spec == nil || !spec.Tf.Intraday() || spec.Bars != components.BarsTime || len(spec.Aggs) > 0 {
+		http.Error(w, "series needs an intraday tf, with time bars and no aggs", http.StatusBadRequest)
+		return
+	}
+
+	// nil re-selects every bucket
+	var reselect []time.Duration
+	if v := q.Get("reselect"); v != "" && v != "bucket" {
+		for _, s := range strings.Split(v, ",") {
+			at, err := parseClock(strings.TrimSpace(s))
+			if err != nil {
+				http.Error(w, "invalid reselect (bucket, or HH:MM:SS times)", http.StatusBadRequest)
+				return
+			}
+			reselect = append(reselect, at)
+		}
+		sort.Slice(reselect, func(i, j int) bool { return reselect[i] < reselect[j] })
+	}
+
+	data, err := components.GetOptionPremiumSeries(
+		underlying,
+		optionType,
+		targetPremium,
+		expiryRule,
+		reselect,
+		from.In(ist),
+		to.In(ist),
+		*spec,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
+	var firstTs, lastTs string
+	if len(data.Ts) > 0 {
+		firstTs = data.Ts[0].Format(time.RFC3339)
+		lastTs = data.Ts[len(data.Ts)-1].Format(time.RFC3339)
+	}
+
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		Expiry:     expiryRule.String(),
+		OptionType: optionType,
+		Mode:       "series",
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+		FirstTs:    firstTs,
+		LastTs:     lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	resampleMeta(&meta, spec)
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
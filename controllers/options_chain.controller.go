@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+func GetOptionChain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	atStr := q.Get("at")
+
+	if underlying == "" || atStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	at, err := time.ParseInLocation("2006-01-02T15:04:05", atStr, loc)
+	if err != nil {
+		http.Error(w, "invalid at", http.StatusBadRequest)
+		return
+	}
+
+	expiry, ok := resolveExpiryAt(w, q, underlying, at)
+	if !ok {
+		return
+	}
+	expiryStr := expiry.Format("2006-01-02")
+
+	data, err := components.GetOptionChain(
+		underlying,
+		expiry,
+		at,
+	)
+	if errors.Is(err, components.ErrNoQuotes) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: models.Meta{
+			Underlying: underlying,
+			Exchange:   calendar.For(underlying).Exchange,
+			Expiry:     expiryStr,
+			At:         at.Format(time.RFC3339),
+		},
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
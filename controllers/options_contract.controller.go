@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"quant-read-api/calendar"
 	"quant-read-api/components"
 	"quant-read-api/models"
 )
@@ -17,15 +18,12 @@ func GetOptionContract(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
 	underlying := q.Get("underlying")
-	expiryStr := q.Get("expiry")
 	strikeStr := q.Get("strike")
 	optionType := q.Get("option_type")
 	fromStr := q.Get("from")
 	toStr := q.Get("to")
-	tfStr := q.Get("tf")
-	offsetStr := q.Get("offset")
 
-	if underlying == "" || expiryStr == "" || strikeStr == "" ||
+	if underlying == "" || strikeStr == "" ||
 		optionType == "" || fromStr == "" || toStr == "" {
 		http.Error(w, "missing query params", http.StatusBadRequest)
 		return
@@ -43,9 +41,9 @@ func GetOptionContract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	expiry, err := time.ParseInLocation("2006-01-02", expiryStr, loc)
+	expiryRule, err := parseExpiryRule(q, false)
 	if err != nil {
-		http.Error(w, "invalid expiry", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -55,40 +53,42 @@ func GetOptionContract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var tfSeconds *int64
-	if tfStr != "" {
-		val, err := parseTF(tfStr)
-		if err != nil {
-			http.Error(w, "invalid tf", http.StatusBadRequest)
-			return
-		}
-		tfSeconds = &val
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	var offsetSeconds int64
-	if offsetStr != "" {
-		offsetSeconds, err = strconv.ParseInt(offsetStr, 10, 64)
-		if err != nil {
-			http.Error(w, "invalid offset", http.StatusBadRequest)
-			return
-		}
+	market, greeks, err := parseMarket(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if market != nil && spec != nil && spec.Bars != components.BarsTime {
+		http.Error(w, "iv and greeks need time bars", http.StatusBadRequest)
+		return
 	}
 
 	data, err := components.GetOptionContract(
 		underlying,
-		expiry,
+		expiryRule,
 		uint32(strike64),
 		optionType,
 		from,
 		to,
-		tfSeconds,
-		offsetSeconds,
+		spec,
+		market,
+		greeks,
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
 	var firstTs, lastTs string
 
 	switch v := data.(type) {
@@ -104,20 +104,25 @@ func GetOptionContract(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		Expiry:     expiryRule.String(),
+		Strike:     uint32(strike64),
+		OptionType: optionType,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+		FirstTs:    firstTs,
+		LastTs:     lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	resampleMeta(&meta, spec)
+
 	resp := models.Response[any]{
 		Data: data,
-		Meta: models.Meta{
-			Underlying: underlying,
-			Expiry:     expiryStr,
-			Strike:     uint32(strike64),
-			OptionType: optionType,
-			From:       from.Format(time.RFC3339),
-			To:         to.Format(time.RFC3339),
-			Tf:         tfStr,
-			Offset:     offsetSeconds,
-			FirstTs:    firstTs,
-			LastTs:     lastTs,
-		},
+		Meta: meta,
 	}
 
 	json.NewEncoder(w).Encode(resp)
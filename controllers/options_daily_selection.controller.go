@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+func GetDailyPremiumSelection(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	optionType := q.Get("option_type")
+	fromStr := q.Get("from")
+	toStr := q.Get("to")
+	atStr := q.Get("at")
+
+	if underlying == "" || optionType == "" || fromStr == "" || toStr == "" || atStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	var optionTypes []string
+	switch optionType {
+	case components.InstrumentCall, components.InstrumentPut:
+		optionTypes = []string{optionType}
+	case "BOTH":
+		optionTypes = []string{components.InstrumentCall, components.InstrumentPut}
+	default:
+		http.Error(w, "invalid option_type (CE, PE, BOTH)", http.StatusBadRequest)
+		return
+	}
+
+	target, err := strconv.ParseFloat(q.Get("target_premium"), 64)
+	if err != nil || target <= 0 {
+		http.Error(w, "invalid target_premium", http.StatusBadRequest)
+		return
+	}
+
+	expiryRule, err := parseExpiryRule(q, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", fromStr, loc)
+	if err != nil {
+		http.Error(w, "invalid from date", http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.ParseInLocation("2006-01-02", toStr, loc)
+	if err != nil || to.Before(from) || to.Sub(from) >= maxBacktestDays*24*time.Hour {
+		http.Error(w, fmt.Sprintf("invalid to date (on or after from, within %d days)", maxBacktestDays), http.StatusBadRequest)
+		return
+	}
+
+	at, err := parseClock(atStr)
+	if err != nil {
+		http.Error(w, "invalid at (HH:MM:SS)", http.StatusBadRequest)
+		return
+	}
+
+	data, err := components.GetDailyPremiumSelection(
+		underlying,
+		optionTypes,
+		target,
+		expiryRule,
+		from,
+		to,
+		at,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to.AddDate(0, 0, 1))
+
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		Expiry:     expiryRule.String(),
+		OptionType: optionType,
+		At:         atStr,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+// maxDeltaCount bounds the strikes returned per option type.
+const maxDeltaCount = 10
+
+func GetOptionContractsByDelta(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	optionType := q.Get("option_type")
+	atStr := q.Get("at")
+
+	if underlying == "" || optionType == "" || atStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	var optionTypes []string
+	switch optionType {
+	case components.InstrumentCall, components.InstrumentPut:
+		optionTypes = []string{optionType}
+	case "BOTH":
+		optionTypes = []string{components.InstrumentCall, components.InstrumentPut}
+	default:
+		http.Error(w, "invalid option_type (CE, PE, BOTH)", http.StatusBadRequest)
+		return
+	}
+
+	// 0.25 and -0.25 both ask for the 25-delta call and put
+	target, err := strconv.ParseFloat(q.Get("delta"), 64)
+	target = math.Abs(target)
+	if err != nil || target <= 0 || target >= 1 {
+		http.Error(w, "invalid delta (between 0 and 1, e.g. 0.25)", http.StatusBadRequest)
+		return
+	}
+
+	count := 1
+	if v := q.Get("count"); v != "" {
+		count, err = strconv.Atoi(v)
+		if err != nil || count < 1 || count > maxDeltaCount {
+			http.Error(w, "invalid count (1 to 10)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	at, err := time.ParseInLocation("2006-01-02T15:04:05", atStr, ist)
+	if err != nil {
+		http.Error(w, "invalid at", http.StatusBadRequest)
+		return
+	}
+
+	market, err := parseCarry(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expiry, ok := resolveExpiryAt(w, q, underlying, at)
+	if !ok {
+		return
+	}
+	expiryStr := expiry.Format("2006-01-02")
+
+	data, err := components.GetOptionContractsByDelta(
+		underlying,
+		expiry,
+		at,
+		optionTypes,
+		target,
+		count,
+		market,
+	)
+	if errors.Is(err, components.ErrNoQuotes) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: models.Meta{
+			Underlying: underlying,
+			Exchange:   calendar.For(underlying).Exchange,
+			Expiry:     expiryStr,
+			OptionType: optionType,
+			At:         at.Format(time.RFC3339),
+		},
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"quant-read-api/calendar"
 	"quant-read-api/components"
 )
 
@@ -19,11 +20,20 @@ func GetOptionExpiries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	detail := q.Get("detail") == "1"
+
+	// v1 reads the window dates as UTC midnights; the detailed listing
+	// reads them as exchange days
+	loc := time.UTC
+	if detail {
+		loc = calendar.For(underlying).Location()
+	}
+
 	var fromPtr *time.Time
 	var toPtr *time.Time
 
 	if fromStr := q.Get("from"); fromStr != "" {
-		from, err := time.Parse("2006-01-02", fromStr)
+		from, err := time.ParseInLocation("2006-01-02", fromStr, loc)
 		if err != nil {
 			http.Error(w, "invalid from date", http.StatusBadRequest)
 			return
@@ -32,7 +42,7 @@ func GetOptionExpiries(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if toStr := q.Get("to"); toStr != "" {
-		to, err := time.Parse("2006-01-02", toStr)
+		to, err := time.ParseInLocation("2006-01-02", toStr, loc)
 		if err != nil {
 			http.Error(w, "invalid to date", http.StatusBadRequest)
 			return
@@ -40,6 +50,21 @@ func GetOptionExpiries(w http.ResponseWriter, r *http.Request) {
 		toPtr = &to
 	}
 
+	if detail {
+		expiries, err := components.GetOptionExpiryDetail(
+			underlying,
+			fromPtr,
+			toPtr,
+		)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(expiries)
+		return
+	}
+
 	expiries, err := components.GetOptionExpiries(
 		underlying,
 		fromPtr,
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+func GetOptionRolling(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	optionType := q.Get("option_type")
+	moneyness := q.Get("moneyness")
+	fromStr := q.Get("from")
+	toStr := q.Get("to")
+
+	if underlying == "" || optionType == "" || fromStr == "" || toStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	if optionType != "CE" && optionType != "PE" {
+		http.Error(w, "invalid option_type (CE, PE)", http.StatusBadRequest)
+		return
+	}
+
+	if moneyness == "" {
+		moneyness = "ATM"
+	}
+
+	moneynessLvl := 0
+
+	switch moneyness {
+	case "ATM":
+	case "ITM", "OTM":
+		v, err := strconv.Atoi(q.Get("moneyness_lvl"))
+		if err != nil || v < 1 {
+			http.Error(w, "invalid moneyness_lvl (1 or more for ITM, OTM)", http.StatusBadRequest)
+			return
+		}
+		moneynessLvl = v
+	default:
+		http.Error(w, "invalid moneyness (ATM, ITM, OTM)", http.StatusBadRequest)
+		return
+	}
+
+	expiryRule, err := parseExpiryRule(q, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := time.ParseInLocation("2006-01-02T15:04:05", fromStr, loc)
+	if err != nil {
+		http.Error(w, "invalid from time", http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.ParseInLocation("2006-01-02T15:04:05", toStr, loc)
+	if err != nil {
+		http.Error(w, "invalid to time", http.StatusBadRequest)
+		return
+	}
+
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if spec != nil && (spec.Bars != components.BarsTime || len(spec.Aggs) > 0) {
+		http.Error(w, "rolling series support time bars only, without aggs", http.StatusBadRequest)
+		return
+	}
+
+	data, err := components.GetOptionRolling(
+		underlying,
+		optionType,
+		moneyness,
+		moneynessLvl,
+		expiryRule,
+		from,
+		to,
+		spec,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
+	var firstTs, lastTs string
+
+	switch v := data.(type) {
+	case models.OptionRollingOHLC:
+		if len(v.Ts) > 0 {
+			firstTs = v.Ts[0].Format(time.RFC3339)
+			lastTs = v.Ts[len(v.Ts)-1].Format(time.RFC3339)
+		}
+	case models.OptionRollingRaw:
+		if len(v.Ts) > 0 {
+			firstTs = v.Ts[0].Format(time.RFC3339)
+			lastTs = v.Ts[len(v.Ts)-1].Format(time.RFC3339)
+		}
+	}
+
+	meta := models.Meta{
+		Underlying:   underlying,
+		Exchange:     exchange.Exchange,
+		Expiry:       expiryRule.String(),
+		OptionType:   optionType,
+		Moneyness:    moneyness,
+		MoneynessLvl: moneynessLvl,
+		From:         from.Format(time.RFC3339),
+		To:           to.Format(time.RFC3339),
+		FirstTs:      firstTs,
+		LastTs:       lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	resampleMeta(&meta, spec)
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -20,7 +20,6 @@ func GetOptionSnapshots(w http.ResponseWriter, r *http.Request) {
 
 	moneyness := q.Get("moneyness")
 	moneynessMode := q.Get("moneyness_mode")
-	expiryMode := q.Get("expiry_mode")
 
 	fromStr := q.Get("from")
 	toStr := q.Get("to")
@@ -32,9 +31,6 @@ func GetOptionSnapshots(w http.ResponseWriter, r *http.Request) {
 	if moneynessMode == "" {
 		moneynessMode = "range"
 	}
-	if expiryMode == "" {
-		expiryMode = "nearest"
-	}
 
 	moneynessLvl := 0
 	if lvlStr := q.Get("moneyness_lvl"); lvlStr != "" {
@@ -67,15 +63,29 @@ func GetOptionSnapshots(w http.ResponseWriter, r *http.Request) {
 	}
 	// -------------------------------------------------
 
+	expiryRule, err := parseExpiryRule(q, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	market, greeks, err := parseMarket(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	rows, err := components.GetOptionSnapshots(
 		underlying,
 		optionType,
 		moneyness,
 		moneynessMode,
 		moneynessLvl,
-		expiryMode,
+		expiryRule,
 		from,
 		to,
+		market,
+		greeks,
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -108,6 +118,10 @@ func GetOptionSnapshots(w http.ResponseWriter, r *http.Request) {
 		resp.Moneyness = append(resp.Moneyness, r.Moneyness)
 		resp.MoneynessLvl = append(resp.MoneynessLvl, r.MoneynessLvl)
 		resp.DaysToExpiry = append(resp.DaysToExpiry, r.DaysToExpiry)
+
+		if market != nil {
+			resp.Append(r.OptionAnalytics, greeks)
+		}
 	}
 
 	json.NewEncoder(w).Encode(resp)
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quant-read-api/calendar"
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+func GetStraddle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	q := r.URL.Query()
+
+	underlying := q.Get("underlying")
+	fromStr := q.Get("from")
+	toStr := q.Get("to")
+
+	if underlying == "" || fromStr == "" || toStr == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+
+	legs := components.StraddleLegs{Mode: q.Get("mode")}
+	if legs.Mode == "" {
+		legs.Mode = components.StraddleATM
+	}
+
+	switch legs.Mode {
+	case components.StraddleATM:
+	case components.StraddleFixed:
+		strike, err := strconv.ParseUint(q.Get("strike"), 10, 32)
+		if err != nil {
+			http.Error(w, "invalid strike", http.StatusBadRequest)
+			return
+		}
+		legs.Strike = uint32(strike)
+	case components.Strangle:
+		width, err := strconv.Atoi(q.Get("width"))
+		if err != nil || width < 1 {
+			http.Error(w, "invalid width (moneyness levels, 1 or more)", http.StatusBadRequest)
+			return
+		}
+		legs.Width = width
+	default:
+		http.Error(w, "invalid mode (atm, fixed, strangle)", http.StatusBadRequest)
+		return
+	}
+
+	expiryRule, err := parseExpiryRule(q, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := time.ParseInLocation("2006-01-02T15:04:05", fromStr, loc)
+	if err != nil {
+		http.Error(w, "invalid from time", http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.ParseInLocation("2006-01-02T15:04:05", toStr, loc)
+	if err != nil {
+		http.Error(w, "invalid to time", http.StatusBadRequest)
+		return
+	}
+
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := components.GetStraddle(
+		underlying,
+		legs,
+		expiryRule,
+		from,
+		to,
+		spec,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exchange := calendar.For(underlying)
+	sessions, skipped := exchange.Sessions(from, to)
+
+	var firstTs, lastTs string
+
+	switch v := data.(type) {
+	case models.ColumnarOHLC:
+		if len(v.Ts) > 0 {
+			firstTs = v.Ts[0].Format(time.RFC3339)
+			lastTs = v.Ts[len(v.Ts)-1].Format(time.RFC3339)
+		}
+	case models.StraddleColumnar:
+		if len(v.Ts) > 0 {
+			firstTs = v.Ts[0].Format(time.RFC3339)
+			lastTs = v.Ts[len(v.Ts)-1].Format(time.RFC3339)
+		}
+	}
+
+	meta := models.Meta{
+		Underlying: underlying,
+		Exchange:   exchange.Exchange,
+		Expiry:     expiryRule.String(),
+		Strike:     legs.Strike,
+		Mode:       legs.Mode,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+		FirstTs:    firstTs,
+		LastTs:     lastTs,
+
+		Sessions:        len(sessions),
+		SkippedSessions: skipped,
+	}
+	if legs.Mode == components.Strangle {
+		meta.Moneyness = "OTM"
+		meta.MoneynessLvl = legs.Width
+	}
+	resampleMeta(&meta, spec)
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
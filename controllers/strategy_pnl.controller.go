@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"quant-read-api/components"
+	"quant-read-api/models"
+)
+
+// maxStrategyLegs bounds the legs of one strategy request.
+const maxStrategyLegs = 16
+
+// strategyRequest is the JSON body of a strategy P&L request.
+type strategyRequest struct {
+	Legs []struct {
+		Underlying   string `json:"underlying"`
+		Instrument   string `json:"instrument"`
+		Expiry       string `json:"expiry"`
+		Nth          int    `json:"nth"`
+		DteMin       *int   `json:"dte_min"`
+		DteMax       *int   `json:"dte_max"`
+		Series       string `json:"series"`
+		Strike       uint32 `json:"strike"`
+		StrikeOffset *int   `json:"strike_offset"`
+		Quantity     int    `json:"quantity"`
+		Side         string `json:"side"`
+		Entry        string `json:"entry"`
+		Exit         string `json:"exit"`
+	} `json:"legs"`
+}
+
+func GetStrategyPnL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+
+	q := r.URL.Query()
+
+	var req strategyRequest
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "invalid strategy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Legs) == 0 || len(req.Legs) > maxStrategyLegs {
+		http.Error(w, fmt.Sprintf("strategy needs 1 to %d legs", maxStrategyLegs), http.StatusBadRequest)
+		return
+	}
+
+	legs := make([]components.StrategyLeg, len(req.Legs))
+	var from, to time.Time
+
+	for i, l := range req.Legs {
+		bad := func(msg string) {
+			http.Error(w, fmt.Sprintf("leg %d: %s", i+1, msg), http.StatusBadRequest)
+		}
+
+		if l.Underlying == "" || l.Entry == "" || l.Exit == "" {
+			bad("missing underlying, entry or exit")
+			return
+		}
+
+		if l.Quantity <= 0 {
+			bad("quantity must be positive")
+			return
+		}
+
+		if l.Side != components.SideBuy && l.Side != components.SideSell {
+			bad("invalid side (buy, sell)")
+			return
+		}
+
+		entry, err := time.ParseInLocation("2006-01-02T15:04:05", l.Entry, loc)
+		if err != nil {
+			bad("invalid entry time")
+			return
+		}
+
+		exit, err := time.ParseInLocation("2006-01-02T15:04:05", l.Exit, loc)
+		if err != nil || !exit.After(entry) {
+			bad("invalid exit time (after entry)")
+			return
+		}
+
+		leg := components.StrategyLeg{
+			Underlying: l.Underlying,
+			Instrument: l.Instrument,
+			Quantity:   l.Quantity,
+			Side:       l.Side,
+			Entry:      entry,
+			Exit:       exit,
+		}
+
+		switch l.Instrument {
+		case components.InstrumentFutures:
+			leg.Series = l.Series
+			if leg.Series == "" {
+				leg.Series = "near"
+			}
+
+		case components.InstrumentCall, components.InstrumentPut:
+			rule, err := newExpiryRule(l.Expiry, l.Nth, l.DteMin, l.DteMax, false)
+			if err != nil {
+				bad(err.Error())
+				return
+			}
+			leg.Expiry = rule
+
+			if (l.Strike == 0) == (l.StrikeOffset == nil) {
+				bad("needs exactly one of strike and strike_offset")
+				return
+			}
+			leg.Strike = l.Strike
+			leg.StrikeOffset = l.StrikeOffset
+
+		default:
+			bad("invalid instrument (CE, PE, FUT)")
+			return
+		}
+
+		if from.IsZero() || entry.Before(from) {
+			from = entry
+		}
+		if exit.After(to) {
+			to = exit
+		}
+
+		legs[i] = leg
+	}
+
+	spec, err := parseResampleSpec(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if spec != nil && (spec.Bars != components.BarsTime ||
+		q.Get("fill") != "" || q.Get("partial") != "" || q.Get("aggs") != "") {
+		http.Error(w, "strategy P&L takes tf, offset, label and closed only", http.StatusBadRequest)
+		return
+	}
+
+	data, err := components.GetStrategyPnL(legs, spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var firstTs, lastTs string
+	if len(data.Ts) > 0 {
+		firstTs = data.Ts[0].Format(time.RFC3339)
+		lastTs = data.Ts[len(data.Ts)-1].Format(time.RFC3339)
+	}
+
+	meta := models.Meta{
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		FirstTs: firstTs,
+		LastTs:  lastTs,
+	}
+	resampleMeta(&meta, spec)
+
+	resp := models.Response[any]{
+		Data: data,
+		Meta: meta,
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -2,26 +2,413 @@ package controllers
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
+
+	"quant-read-api/analytics"
+	"quant-read-api/components"
+	"quant-read-api/models"
 )
 
-func parseTF(tf string) (int64, error) {
+// parseTF parses a timeframe such as 30s, 5m, 75m, 1h, 1d, 1w or 1M.
+// Units are case sensitive: m is minutes, M is months.
+func parseTF(tf string) (components.Timeframe, error) {
 	if len(tf) < 2 {
-		return 0, fmt.Errorf("invalid tf")
+		return components.Timeframe{}, fmt.Errorf("invalid tf")
 	}
 
 	unit := tf[len(tf)-1]
 	value, err := strconv.ParseInt(tf[:len(tf)-1], 10, 64)
 	if err != nil {
-		return 0, err
+		return components.Timeframe{}, err
+	}
+
+	if value <= 0 {
+		return components.Timeframe{}, fmt.Errorf("invalid tf value")
 	}
 
 	switch unit {
-	case 's':
-		return value, nil
-	case 'm':
-		return value * 60, nil
+	case 's', 'm', 'h', 'd', 'w', 'M':
+		return components.Timeframe{Value: value, Unit: unit}, nil
+	default:
+		return components.Timeframe{}, fmt.Errorf("invalid tf unit")
+	}
+}
+
+// parseResampleSpec reads tf, offset, fill, label, closed, partial, aggs
+// and bars. It returns nil when neither tf nor a non-time bar type was
+// requested, meaning raw rows.
+func parseResampleSpec(q url.Values) (*components.ResampleSpec, error) {
+	tfStr := q.Get("tf")
+	barsMode := q.Get("bars")
+
+	timeBars := barsMode == "" || barsMode == components.BarsTime ||
+		barsMode == components.BarsHeikinAshi
+
+	switch barsMode {
+	case components.BarsRenko, components.BarsRange, components.BarsTick:
+		for _, param := range []string{"tf", "offset", "aggs"} {
+			if q.Get(param) != "" {
+				return nil, fmt.Errorf("%s does not apply to bars=%s", param, barsMode)
+			}
+		}
+	}
+
+	if tfStr == "" && timeBars {
+		if barsMode != "" {
+			return nil, fmt.Errorf("bars=%s needs tf", barsMode)
+		}
+		return nil, nil
+	}
+
+	var tf components.Timeframe
+	var err error
+
+	if tfStr != "" {
+		tf, err = parseTF(tfStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tf")
+		}
+	}
+
+	spec := &components.ResampleSpec{
+		Tf:      tf,
+		Fill:    components.FillNone,
+		Label:   components.LabelStart,
+		Closed:  components.ClosedLeft,
+		Partial: components.PartialDrop,
+	}
+
+	if offsetStr := q.Get("offset"); offsetStr != "" {
+		if !tf.Intraday() {
+			return nil, fmt.Errorf("offset only applies to intraday tf (s, m, h)")
+		}
+		spec.Offset, err = strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset")
+		}
+	}
+
+	if fill := q.Get("fill"); fill != "" {
+		switch fill {
+		case components.FillNone, components.FillForward, components.FillNull:
+			spec.Fill = fill
+		default:
+			return nil, fmt.Errorf("invalid fill (none, ffill, null)")
+		}
+	}
+
+	if label := q.Get("label"); label != "" {
+		switch label {
+		case components.LabelStart, components.LabelEnd:
+			spec.Label = label
+		default:
+			return nil, fmt.Errorf("invalid label (start, end)")
+		}
+	}
+
+	if closed := q.Get("closed"); closed != "" {
+		switch closed {
+		case components.ClosedLeft, components.ClosedRight:
+			spec.Closed = closed
+		default:
+			return nil, fmt.Errorf("invalid closed (left, right)")
+		}
+	}
+
+	if partial := q.Get("partial"); partial != "" {
+		switch partial {
+		case components.PartialDrop, components.PartialInclude, components.PartialFlag:
+			spec.Partial = partial
+		default:
+			return nil, fmt.Errorf("invalid partial (drop, include, flag)")
+		}
+	}
+
+	if aggs := q.Get("aggs"); aggs != "" {
+		seen := map[string]bool{}
+		for _, a := range strings.Split(aggs, ",") {
+			if seen[a] {
+				continue
+			}
+			seen[a] = true
+
+			switch a {
+			case components.AggCount, components.AggMean, components.AggTwap,
+				components.AggStddev, components.AggFirstTs, components.AggLastTs:
+				spec.Aggs = append(spec.Aggs, a)
+			default:
+				return nil, fmt.Errorf("invalid aggs (count, mean, twap, stddev, first_ts, last_ts)")
+			}
+		}
+	}
+
+	spec.Bars = components.BarsTime
+
+	switch barsMode {
+	case "", components.BarsTime:
+	case components.BarsHeikinAshi:
+		spec.Bars = barsMode
+
+	case components.BarsRenko:
+		brick := q.Get("brick")
+		if strings.HasSuffix(brick, "%") {
+			spec.BarPercent = true
+			brick = strings.TrimSuffix(brick, "%")
+		}
+		spec.BarSize, err = strconv.ParseFloat(brick, 64)
+		if err != nil || spec.BarSize <= 0 {
+			return nil, fmt.Errorf("invalid brick (points, or percent like 0.5%%)")
+		}
+		spec.Bars = barsMode
+
+	case components.BarsRange:
+		spec.BarSize, err = strconv.ParseFloat(q.Get("range"), 64)
+		if err != nil || spec.BarSize <= 0 {
+			return nil, fmt.Errorf("invalid range")
+		}
+		spec.Bars = barsMode
+
+	case components.BarsTick:
+		ticks, err := strconv.Atoi(q.Get("ticks"))
+		if err != nil || ticks <= 0 {
+			return nil, fmt.Errorf("invalid ticks")
+		}
+		spec.BarSize = float64(ticks)
+		spec.Bars = barsMode
+
 	default:
-		return 0, fmt.Errorf("invalid tf unit")
+		return nil, fmt.Errorf("invalid bars (time, heikin_ashi, renko, range, tick)")
+	}
+
+	return spec, nil
+}
+
+// resampleMeta echoes the resample spec into the response meta.
+func resampleMeta(meta *models.Meta, spec *components.ResampleSpec) {
+	if spec == nil {
+		return
+	}
+
+	meta.Bars = spec.Bars
+
+	switch spec.Bars {
+	case components.BarsTime, components.BarsHeikinAshi:
+		meta.Tf = spec.Tf.String()
+		meta.Offset = spec.Offset
+		meta.Fill = spec.Fill
+		meta.Label = spec.Label
+		meta.Closed = spec.Closed
+		meta.Partial = spec.Partial
+		meta.Aggs = spec.Aggs
+
+	default:
+		meta.BarSize = strconv.FormatFloat(spec.BarSize, 'f', -1, 64)
+		if spec.BarPercent {
+			meta.BarSize += "%"
+		}
+	}
+}
+
+// Default carry for implied volatility: an Indian T-bill rate and no
+// dividend yield.
+const (
+	defaultRate     = 0.065
+	defaultDivYield = 0.0
+)
+
+// parseMarket reads iv, greeks, rate and div_yield. The market is nil
+// unless iv=1 or greeks=1; greeks imply iv, which they are computed at.
+func parseMarket(q url.Values) (*analytics.Market, bool, error) {
+	greeks := q.Get("greeks") == "1"
+
+	if q.Get("iv") != "1" && !greeks {
+		return nil, false, nil
+	}
+
+	market, err := parseCarry(q)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &market, greeks, nil
+}
+
+// parseCarry reads rate and div_yield, annual decimals (6.5% is 0.065).
+func parseCarry(q url.Values) (analytics.Market, error) {
+	market := analytics.Market{
+		Rate:     defaultRate,
+		DivYield: defaultDivYield,
 	}
+
+	var err error
+
+	if v := q.Get("rate"); v != "" {
+		market.Rate, err = strconv.ParseFloat(v, 64)
+		if err != nil || market.Rate < -1 || market.Rate > 1 {
+			return market, fmt.Errorf("invalid rate (annual decimal, e.g. 0.065)")
+		}
+	}
+
+	if v := q.Get("div_yield"); v != "" {
+		market.DivYield, err = strconv.ParseFloat(v, 64)
+		if err != nil || market.DivYield < -1 || market.DivYield > 1 {
+			return market, fmt.Errorf("invalid div_yield (annual decimal, e.g. 0.012)")
+		}
+	}
+
+	return market, nil
+}
+
+// maxGridPoints bounds interpolated smiles.
+const maxGridPoints = 1001
+
+// parseMoneynessGrid reads grid_step, grid_min and grid_max. It returns
+// nil unless grid_step is set; the range defaults to ±0.2.
+func parseMoneynessGrid(q url.Values) (*components.MoneynessGrid, error) {
+	stepStr := q.Get("grid_step")
+	if stepStr == "" {
+		return nil, nil
+	}
+
+	grid := &components.MoneynessGrid{Min: -0.2, Max: 0.2}
+
+	var err error
+
+	grid.Step, err = strconv.ParseFloat(stepStr, 64)
+	if err != nil || grid.Step <= 0 {
+		return nil, fmt.Errorf("invalid grid_step")
+	}
+
+	if v := q.Get("grid_min"); v != "" {
+		grid.Min, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grid_min")
+		}
+	}
+
+	if v := q.Get("grid_max"); v != "" {
+		grid.Max, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grid_max")
+		}
+	}
+
+	if grid.Max < grid.Min || (grid.Max-grid.Min)/grid.Step >= maxGridPoints {
+		return nil, fmt.Errorf("grid needs grid_min <= grid_max and at most %d points", maxGridPoints)
+	}
+
+	return grid, nil
+}
+
+// parseExpiryRule reads expiry, nth, dte_min and dte_max. The older
+// expiry_mode is read as expiry when expiry is absent.
+func parseExpiryRule(q url.Values, allowAll bool) (components.ExpiryRule, error) {
+	expiry := q.Get("expiry")
+	if expiry == "" {
+		expiry = q.Get("expiry_mode")
+	}
+
+	nth := 0
+	if v := q.Get("nth"); v != "" {
+		var err error
+		nth, err = strconv.Atoi(v)
+		if err != nil || nth < 1 {
+			return components.ExpiryRule{}, fmt.Errorf("invalid nth")
+		}
+	}
+
+	dte := func(name string) (*int, error) {
+		v := q.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s", name)
+		}
+		return &n, nil
+	}
+
+	dteMin, err := dte("dte_min")
+	if err != nil {
+		return components.ExpiryRule{}, err
+	}
+	dteMax, err := dte("dte_max")
+	if err != nil {
+		return components.ExpiryRule{}, err
+	}
+
+	return newExpiryRule(expiry, nth, dteMin, dteMax, allowAll)
+}
+
+// newExpiryRule validates an expiry rule. expiry is a rule kind or a
+// YYYY-MM-DD date, nearest when empty; nth and the DTE bounds refine a
+// kind. all is accepted only by endpoints returning rows of any expiry.
+func newExpiryRule(expiry string, nth int, dteMin, dteMax *int, allowAll bool) (components.ExpiryRule, error) {
+	if expiry == "" {
+		expiry = components.ExpiryNearest
+	}
+
+	rule := components.ExpiryRule{Nth: nth, DteMin: dteMin, DteMax: dteMax}
+
+	switch expiry {
+	case components.ExpiryNearest, components.ExpiryNext,
+		components.ExpiryWeekly, components.ExpiryMonthly:
+		rule.Kind = expiry
+
+	case components.ExpiryAll:
+		if !allowAll {
+			return rule, fmt.Errorf("expiry=all is not supported here")
+		}
+		rule.Kind = expiry
+
+	default:
+		if _, err := time.Parse("2006-01-02", expiry); err != nil {
+			return rule, fmt.Errorf("invalid expiry (nearest, next, weekly, monthly, all or YYYY-MM-DD)")
+		}
+		if nth != 0 || dteMin != nil || dteMax != nil {
+			return rule, fmt.Errorf("nth, dte_min and dte_max do not apply to a literal expiry")
+		}
+		return components.ExpiryOn(expiry), nil
+	}
+
+	if nth < 0 || (dteMin != nil && *dteMin < 0) || (dteMax != nil && *dteMax < 0) {
+		return rule, fmt.Errorf("nth, dte_min and dte_max cannot be negative")
+	}
+	if nth != 0 && (rule.Kind == components.ExpiryNext || rule.Kind == components.ExpiryAll) {
+		return rule, fmt.Errorf("nth does not apply to expiry=%s", rule.Kind)
+	}
+	if dteMin != nil && dteMax != nil && *dteMax < *dteMin {
+		return rule, fmt.Errorf("dte_max is below dte_min")
+	}
+
+	return rule, nil
+}
+
+// resolveExpiryAt resolves the request's expiry rule on the day of at,
+// for endpoints reading one point in time. It writes the error and
+// returns false when the rule is invalid or resolves to nothing.
+func resolveExpiryAt(w http.ResponseWriter, q url.Values, underlying string, at time.Time) (time.Time, bool) {
+	rule, err := parseExpiryRule(q, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return time.Time{}, false
+	}
+
+	resolved, err := components.ResolveExpiry(underlying, at, rule)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return time.Time{}, false
+	}
+	if resolved == "" {
+		http.Error(w, "no expiry matching "+rule.String()+" listed on or after at", http.StatusNotFound)
+		return time.Time{}, false
+	}
+
+	expiry, _ := time.ParseInLocation("2006-01-02", resolved, at.Location())
+	return expiry, true
 }
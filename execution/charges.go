@@ -0,0 +1,90 @@
+// Package execution models how orders turn into fills: latency is applied
+// by the caller, slippage and tick rounding here, and the statutory and
+// broker charges of Indian F&O trades.
+package execution
+
+import (
+	"math"
+
+	"quant-read-api/models"
+)
+
+// Rates are the turnover-based charges of one product, as decimal
+// fractions (0.001 is 0.1%).
+type Rates struct {
+	STTSell  float64 `json:"stt_sell"`  // securities transaction tax, sell side
+	Exchange float64 `json:"exchange"`  // exchange transaction charges
+	StampBuy float64 `json:"stamp_buy"` // stamp duty, buy side
+}
+
+// Schedule is a full charge schedule. Brokerage is BrokeragePerOrder,
+// or BrokeragePct of turnover capped at BrokeragePerOrder when both are
+// set. GST is levied on brokerage, exchange charges and the SEBI fee.
+type Schedule struct {
+	BrokeragePerOrder float64 `json:"brokerage_per_order"`
+	BrokeragePct      float64 `json:"brokerage_pct"`
+	SEBI              float64 `json:"sebi"`
+	GST               float64 `json:"gst"`
+
+	Options Rates `json:"options"` // on premium turnover
+	Futures Rates `json:"futures"` // on contract value
+}
+
+// NSE is the NSE F&O schedule in force since October 2024, with a flat
+// discount-broker brokerage.
+var NSE = Schedule{
+	BrokeragePerOrder: 20,
+	SEBI:              0.000001, // ₹10 per crore
+	GST:               0.18,
+
+	Options: Rates{
+		STTSell:  0.001,
+		Exchange: 0.0003503,
+		StampBuy: 0.00003,
+	},
+	Futures: Rates{
+		STTSell:  0.0002,
+		Exchange: 0.0000173,
+		StampBuy: 0.00002,
+	},
+}
+
+// Costs returns the charges of one fill of quantity units at price. Each
+// charge is rounded to the paisa, as on a contract note.
+func (s Schedule) Costs(futures, buy bool, price float64, quantity int) models.FillCharges {
+	rates := s.Options
+	if futures {
+		rates = s.Futures
+	}
+
+	turnover := price * float64(quantity)
+
+	var c models.FillCharges
+
+	c.Brokerage = s.BrokeragePerOrder
+	if s.BrokeragePct > 0 {
+		c.Brokerage = turnover * s.BrokeragePct
+		if s.BrokeragePerOrder > 0 {
+			c.Brokerage = min(c.Brokerage, s.BrokeragePerOrder)
+		}
+	}
+	c.Brokerage = paise(c.Brokerage)
+
+	if buy {
+		c.StampDuty = paise(turnover * rates.StampBuy)
+	} else {
+		c.STT = paise(turnover * rates.STTSell)
+	}
+
+	c.Exchange = paise(turnover * rates.Exchange)
+	c.SEBI = paise(turnover * s.SEBI)
+	c.GST = paise((c.Brokerage + c.Exchange + c.SEBI) * s.GST)
+
+	c.Total = paise(c.Brokerage + c.STT + c.Exchange + c.SEBI + c.GST + c.StampDuty)
+
+	return c
+}
+
+func paise(v float64) float64 {
+	return math.Round(v*100) / 100
+}
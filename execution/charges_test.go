@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"testing"
+
+	"quant-read-api/models"
+)
+
+// Worked examples at the NSE schedule, one line per charge as printed on a
+// contract note: each rounded to the paisa, GST on the rounded brokerage,
+// exchange and SEBI lines, the total summed from the rounded lines.
+func TestCosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        Schedule
+		futures  bool
+		buy      bool
+		price    float64
+		quantity int
+		want     models.FillCharges
+	}{
+		{
+			// 75 × ₹100 = ₹7,500 premium
+			name: "options buy", s: NSE, buy: true, price: 100, quantity: 75,
+			want: models.FillCharges{
+				Brokerage: 20,
+				Exchange:  2.63, // 7500 × 0.03503% = 2.62725
+				SEBI:      0.01, // 7500 × ₹10/crore = 0.0075
+				GST:       4.08, // 18% of 22.64
+				StampDuty: 0.23, // 7500 × 0.003% = 0.225
+				Total:     26.95,
+			},
+		},
+		{
+			name: "options sell", s: NSE, buy: false, price: 100, quantity: 75,
+			want: models.FillCharges{
+				Brokerage: 20,
+				STT:       7.5, // 7500 × 0.1%
+				Exchange:  2.63,
+				SEBI:      0.01,
+				GST:       4.08,
+				Total:     34.22,
+			},
+		},
+		{
+			// 75 × ₹25,000 = ₹18,75,000 contract value
+			name: "futures buy", s: NSE, futures: true, buy: true, price: 25000, quantity: 75,
+			want: models.FillCharges{
+				Brokerage: 20,
+				Exchange:  32.44, // 1875000 × 0.00173% = 32.4375
+				SEBI:      1.88,  // 1.875
+				GST:       9.78,  // 18% of 54.32
+				StampDuty: 37.5,  // 1875000 × 0.002%
+				Total:     101.6,
+			},
+		},
+		{
+			name: "futures sell", s: NSE, futures: true, buy: false, price: 25000, quantity: 75,
+			want: models.FillCharges{
+				Brokerage: 20,
+				STT:       375, // 1875000 × 0.02%
+				Exchange:  32.44,
+				SEBI:      1.88,
+				GST:       9.78,
+				Total:     439.1,
+			},
+		},
+		{
+			// 0.03% of 7500 is under the ₹20 cap
+			name: "percent brokerage under the cap", s: withPct(0.0003), buy: true, price: 100, quantity: 75,
+			want: models.FillCharges{
+				Brokerage: 2.25,
+				Exchange:  2.63,
+				SEBI:      0.01,
+				GST:       0.88, // 18% of 4.89
+				StampDuty: 0.23,
+				Total:     6,
+			},
+		},
+		{
+			// 0.03% of 18,75,000 is 562.50, capped at ₹20
+			name: "percent brokerage capped", s: withPct(0.0003), futures: true, buy: false, price: 25000, quantity: 75,
+			want: models.FillCharges{
+				Brokerage: 20,
+				STT:       375,
+				Exchange:  32.44,
+				SEBI:      1.88,
+				GST:       9.78,
+				Total:     439.1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.s.Costs(tt.futures, tt.buy, tt.price, tt.quantity)
+			if got != tt.want {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func withPct(pct float64) Schedule {
+	s := NSE
+	s.BrokeragePct = pct
+	return s
+}
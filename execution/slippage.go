@@ -0,0 +1,53 @@
+package execution
+
+import "math"
+
+// Slippage models.
+const (
+	SlippageNone   = "none"
+	SlippagePoints = "points" // a fixed price move per unit
+	SlippageBps    = "bps"    // basis points of the price
+)
+
+// Slippage moves a market fill against the order.
+type Slippage struct {
+	Model string
+	Value float64
+}
+
+// Apply returns price after slippage: higher for a buy, lower for a sell,
+// never below zero.
+func (s Slippage) Apply(price float64, buy bool) float64 {
+	var move float64
+
+	switch s.Model {
+	case SlippagePoints:
+		move = s.Value
+	case SlippageBps:
+		move = price * s.Value / 10000
+	}
+
+	if !buy {
+		move = -move
+	}
+
+	return max(price+move, 0)
+}
+
+// RoundToTick rounds price onto the tick grid against the order: up for a
+// buy, down for a sell. A non-positive tick leaves the price unchanged.
+func RoundToTick(price, tick float64, buy bool) float64 {
+	if tick <= 0 {
+		return price
+	}
+
+	// absorb float noise so a price already on the grid stays put
+	ticks := math.Round(price/tick*1e6) / 1e6
+	if buy {
+		ticks = math.Ceil(ticks)
+	} else {
+		ticks = math.Floor(ticks)
+	}
+
+	return math.Round(ticks*tick*1e6) / 1e6
+}
@@ -0,0 +1,59 @@
+package execution
+
+import "testing"
+
+func TestSlippageApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     Slippage
+		price float64
+		buy   bool
+		want  float64
+	}{
+		{"none", Slippage{Model: SlippageNone}, 100, true, 100},
+		{"points buy", Slippage{Model: SlippagePoints, Value: 0.5}, 100, true, 100.5},
+		{"points sell", Slippage{Model: SlippagePoints, Value: 0.5}, 100, false, 99.5},
+		{"bps buy", Slippage{Model: SlippageBps, Value: 10}, 250, true, 250.25},
+		{"bps sell", Slippage{Model: SlippageBps, Value: 10}, 250, false, 249.75},
+		{"sell floored at zero", Slippage{Model: SlippagePoints, Value: 2}, 1.5, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Apply(tt.price, tt.buy); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundToTick(t *testing.T) {
+	tests := []struct {
+		name  string
+		price float64
+		tick  float64
+		buy   bool
+		want  float64
+	}{
+		{"buy rounds up", 100.01, 0.05, true, 100.05},
+		{"sell rounds down", 100.04, 0.05, false, 100},
+		{"buy on the grid stays", 100.05, 0.05, true, 100.05},
+		{"sell on the grid stays", 100.05, 0.05, false, 100.05},
+		// 0.1 + 0.2 is 0.30000000000000004, still on the grid
+		{"float noise buy", 0.1 + 0.2, 0.05, true, 0.3},
+		{"float noise sell", 0.1 + 0.2, 0.05, false, 0.3},
+		{"bps slippage buy", 250.25, 0.05, true, 250.25},
+		{"bps slippage sell", 99.912, 0.05, false, 99.9},
+		{"bps slippage buy off grid", 99.912, 0.05, true, 99.95},
+		{"coarser tick", 25012.37, 0.1, true, 25012.4},
+		{"no tick", 100.01, 0, true, 100.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundToTick(tt.price, tt.tick, tt.buy); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 
+	"quant-read-api/calendar"
+	"quant-read-api/components"
 	"quant-read-api/routes"
 	"quant-read-api/services"
 
@@ -11,10 +14,32 @@ import (
 )
 
 func main() {
+	checkCalendar := flag.Bool("check-calendar", false, "list trading days in the data the calendar does not know, then exit")
+	flag.Parse()
+
+	if err := calendar.Load("config/calendar.json"); err != nil {
+		log.Fatal("Trading calendar load failed:", err)
+	}
+
 	if err := services.InitClickHouse("clickhouse://localhost:9000/second_data"); err != nil {
 		log.Fatal("ClickHouse connection failed:", err)
 	}
 
+	if *checkCalendar {
+		missing, err := components.CheckCalendar()
+		if err != nil {
+			log.Fatal("Calendar check failed:", err)
+		}
+		for _, m := range missing {
+			log.Println(m)
+		}
+		if len(missing) > 0 {
+			log.Fatalf("Calendar check failed: %d trading days missing", len(missing))
+		}
+		log.Println("Calendar covers every trading day in the data")
+		return
+	}
+
 	r := mux.NewRouter()
 	routes.ServeRoutes(r)
 
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Backtest is the result of replaying a strategy over a date range.
+type Backtest struct {
+	Trades  []BacktestTrade  `json:"trades"`
+	Daily   BacktestDaily    `json:"daily"`
+	Summary BacktestSummary  `json:"summary"`
+	Skipped []SkippedSession `json:"skipped,omitempty"`
+}
+
+// BacktestTrade is one leg of one day, from entry fill to exit fill.
+type BacktestTrade struct {
+	Date       string `json:"date"`
+	Leg        int    `json:"leg"`
+	Expiry     string `json:"expiry"`
+	Strike     uint32 `json:"strike"`
+	OptionType string `json:"option_type"`
+	Side       string `json:"side"`
+	Quantity   int    `json:"quantity"`
+
+	EntryTs    time.Time `json:"entry_ts"`
+	EntryPrice float64   `json:"entry_price"`
+	EntrySpot  float64   `json:"entry_spot"`
+
+	ExitTs     time.Time `json:"exit_ts"`
+	ExitPrice  float64   `json:"exit_price"`
+	ExitSpot   float64   `json:"exit_spot"`
+	ExitReason string    `json:"exit_reason"`
+
+	Pnl float64 `json:"pnl"`
+}
+
+// BacktestDaily is the P&L of every traded day, with its running total
+// and the drawdown from the running peak.
+type BacktestDaily struct {
+	Date       []string    `json:"date"`
+	Trades     []int       `json:"trades"`
+	Pnl        FloatColumn `json:"pnl"`
+	Cumulative FloatColumn `json:"cumulative"`
+	Drawdown   FloatColumn `json:"drawdown"`
+}
+
+// BacktestSummary is the headline statistics of a run. Ratios that have
+// nothing to divide by are omitted.
+type BacktestSummary struct {
+	Days   int `json:"days"`
+	Trades int `json:"trades"`
+
+	NetPnl   float64 `json:"net_pnl"`
+	WinDays  int     `json:"win_days"`
+	LossDays int     `json:"loss_days"`
+	WinRate  float64 `json:"win_rate"` // % of days
+	BestDay  float64 `json:"best_day"`
+	WorstDay float64 `json:"worst_day"`
+
+	AvgWinDay    *float64 `json:"avg_win_day,omitempty"`
+	AvgLossDay   *float64 `json:"avg_loss_day,omitempty"`
+	ProfitFactor *float64 `json:"profit_factor,omitempty"`
+	MaxDrawdown  float64  `json:"max_drawdown"`
+	Sharpe       *float64 `json:"sharpe,omitempty"` // annualised, on daily P&L
+
+	TradeWinRate float64  `json:"trade_win_rate"`       // % of trades
+	Expectancy   *float64 `json:"expectancy,omitempty"` // mean P&L per trade
+}
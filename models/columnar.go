@@ -4,8 +4,25 @@ import "time"
 
 type ColumnarOHLC struct {
 	Ts    []time.Time `json:"ts"`
-	Open  []float64   `json:"open"`
-	High  []float64   `json:"high"`
-	Low   []float64   `json:"low"`
-	Close []float64   `json:"close"`
+	Open  FloatColumn `json:"open"`
+	High  FloatColumn `json:"high"`
+	Low   FloatColumn `json:"low"`
+	Close FloatColumn `json:"close"`
+
+	// optional bucket aggregations (aggs=)
+
+	// Count is the ticks in the bucket. Renko bricks completed by the same
+	// tick as the brick before them count 0.
+	Count   []uint64    `json:"count,omitempty"`
+	Mean    FloatColumn `json:"mean,omitempty"`
+	Twap    FloatColumn `json:"twap,omitempty"`
+	Stddev  FloatColumn `json:"stddev,omitempty"`
+	FirstTs TimeColumn  `json:"first_ts,omitempty"`
+	LastTs  TimeColumn  `json:"last_ts,omitempty"`
+
+	// implied volatility and greeks of the close, on option contracts
+	OptionAnalyticsColumns
+
+	Filled  []bool `json:"filled,omitempty"`
+	Partial []bool `json:"partial,omitempty"`
 }
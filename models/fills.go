@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// FillReport is the outcome of simulating a list of orders.
+type FillReport struct {
+	Fills  []Fill     `json:"fills"`
+	Totals FillTotals `json:"totals"`
+}
+
+// Fill is one simulated order. Prices are omitted when it did not fill.
+type Fill struct {
+	Order     int       `json:"order"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	ArrivalTs time.Time `json:"arrival_ts"`
+	Expiry    string    `json:"expiry,omitempty"` // options: the expiry the order resolved to
+
+	ReferencePrice *float64 `json:"reference_price,omitempty"` // last print at the order time
+	MarketPrice    *float64 `json:"market_price,omitempty"`    // print filled against
+	FillTs         string   `json:"fill_ts,omitempty"`
+	FillPrice      *float64 `json:"fill_price,omitempty"`
+	Slippage       *float64 `json:"slippage,omitempty"` // per unit, against the order, from the reference
+
+	Turnover float64     `json:"turnover"`
+	Charges  FillCharges `json:"charges"`
+}
+
+// FillCharges is the transaction cost of a fill, in currency.
+type FillCharges struct {
+	Brokerage float64 `json:"brokerage"`
+	STT       float64 `json:"stt"`
+	Exchange  float64 `json:"exchange"`
+	SEBI      float64 `json:"sebi"`
+	GST       float64 `json:"gst"`
+	StampDuty float64 `json:"stamp_duty"`
+	Total     float64 `json:"total"`
+}
+
+// Add accumulates another fill's charges.
+func (c *FillCharges) Add(o FillCharges) {
+	c.Brokerage += o.Brokerage
+	c.STT += o.STT
+	c.Exchange += o.Exchange
+	c.SEBI += o.SEBI
+	c.GST += o.GST
+	c.StampDuty += o.StampDuty
+	c.Total += o.Total
+}
+
+// FillTotals sums a report's fills.
+type FillTotals struct {
+	Orders       int         `json:"orders"`
+	Filled       int         `json:"filled"`
+	Turnover     float64     `json:"turnover"`
+	SlippageCost float64     `json:"slippage_cost"`
+	Charges      FillCharges `json:"charges"`
+}
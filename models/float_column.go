@@ -0,0 +1,53 @@
+package models
+
+import (
+	"math"
+	"strconv"
+)
+
+// FloatColumn is a float64 column that encodes NaN and ±Inf as JSON null,
+// so missing values can travel inside otherwise dense columns.
+type FloatColumn []float64
+
+func (c FloatColumn) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+
+	b := make([]byte, 0, len(c)*10+2)
+	b = append(b, '[')
+
+	for i, f := range c {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendFloat(b, f)
+	}
+
+	return append(b, ']'), nil
+}
+
+// appendFloat formats f the way encoding/json does.
+func appendFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(b, "null"...)
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+
+	return b
+}
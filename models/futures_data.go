@@ -8,3 +8,27 @@ type FuturesDataRow struct {
 	Underlying   string    `json:"underlying"`
 	Series       string    `json:"series"`
 }
+
+// FuturesRoll is one contract roll of a continuous futures series.
+type FuturesRoll struct {
+	Ts         string  `json:"ts"`
+	Expiry     string  `json:"expiry"`
+	Near       float64 `json:"near_price"`
+	Next       float64 `json:"next_price"`
+	Adjustment float64 `json:"adjustment,omitempty"`
+}
+
+// FuturesBasisColumnar is futures against spot, as-of joined on time.
+type FuturesBasisColumnar struct {
+	Ts              []time.Time `json:"ts"`
+	Expiry          []string    `json:"expiry"`
+	Futures         FloatColumn `json:"futures"`
+	Spot            FloatColumn `json:"spot"`
+	Basis           FloatColumn `json:"basis"`
+	BasisPct        FloatColumn `json:"basis_pct"`
+	DaysToExpiry    FloatColumn `json:"days_to_expiry"`
+	AnnualizedCarry FloatColumn `json:"annualized_carry"`
+
+	Filled  []bool `json:"filled,omitempty"`
+	Partial []bool `json:"partial,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// IVSmile is the out-of-the-money implied volatility of one expiry as of
+// a point in time: puts below the ATM strike, calls above, both averaged
+// at it. Moneyness is ln(strike / forward).
+type IVSmile struct {
+	Expiry       string  `json:"expiry"`
+	DaysToExpiry float64 `json:"days_to_expiry"`
+	Forward      float64 `json:"forward"`
+	AtmStrike    uint32  `json:"atm_strike"`
+
+	Strike     []uint32    `json:"strike"`
+	OptionType []string    `json:"option_type"`
+	Moneyness  FloatColumn `json:"moneyness"`
+	IV         FloatColumn `json:"iv"`
+	IVStatus   []string    `json:"iv_status"`
+	Staleness  FloatColumn `json:"staleness"`
+
+	// iv interpolated onto IVSurface.Grid, with a grid
+	GridIV FloatColumn `json:"grid_iv,omitempty"`
+}
+
+// IVSurface is the smile of every listed expiry, nearest first.
+type IVSurface struct {
+	Spot   float64   `json:"spot"`
+	SpotTs time.Time `json:"spot_ts"`
+
+	Grid   FloatColumn `json:"grid,omitempty"`
+	Smiles []IVSmile   `json:"smiles"`
+}
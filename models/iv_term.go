@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// IVTermStructure is the ATM implied volatility of the nearest expiries
+// over time, with a constant-maturity volatility index interpolated from
+// them.
+type IVTermStructure struct {
+	Ts    []time.Time `json:"ts"`
+	Index FloatColumn `json:"index"`
+	Terms []IVTerm    `json:"terms"`
+
+	Filled  []bool `json:"filled,omitempty"`
+	Partial []bool `json:"partial,omitempty"`
+}
+
+// IVTerm is the nth nearest expiry at every ts, so its expiry changes as
+// contracts roll off. Ranks with no listed expiry are empty and null.
+type IVTerm struct {
+	Rank         int         `json:"rank"`
+	Expiry       []string    `json:"expiry"`
+	DaysToExpiry FloatColumn `json:"days_to_expiry"`
+	AtmStrike    []uint32    `json:"atm_strike"`
+	IV           FloatColumn `json:"iv"`
+	IVStatus     []string    `json:"iv_status"`
+}
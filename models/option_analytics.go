@@ -0,0 +1,47 @@
+package models
+
+import "math"
+
+// OptionAnalytics is the implied volatility and greeks of one option row,
+// filled with iv=1 or greeks=1. Values are omitted when they cannot be
+// solved; IVStatus says why.
+type OptionAnalytics struct {
+	IV       *float64 `json:",omitempty"`
+	IVStatus string   `json:",omitempty"`
+
+	Delta *float64 `json:",omitempty"`
+	Gamma *float64 `json:",omitempty"`
+	Vega  *float64 `json:",omitempty"`
+	Theta *float64 `json:",omitempty"`
+}
+
+// OptionAnalyticsColumns is the columnar form of OptionAnalytics.
+type OptionAnalyticsColumns struct {
+	IV       FloatColumn `json:"iv,omitempty"`
+	IVStatus []string    `json:"iv_status,omitempty"`
+
+	Delta FloatColumn `json:"delta,omitempty"`
+	Gamma FloatColumn `json:"gamma,omitempty"`
+	Vega  FloatColumn `json:"vega,omitempty"`
+	Theta FloatColumn `json:"theta,omitempty"`
+}
+
+// Append adds one row's values, null where they were not solved.
+func (c *OptionAnalyticsColumns) Append(a OptionAnalytics, greeks bool) {
+	c.IV = append(c.IV, floatOrNaN(a.IV))
+	c.IVStatus = append(c.IVStatus, a.IVStatus)
+
+	if greeks {
+		c.Delta = append(c.Delta, floatOrNaN(a.Delta))
+		c.Gamma = append(c.Gamma, floatOrNaN(a.Gamma))
+		c.Vega = append(c.Vega, floatOrNaN(a.Vega))
+		c.Theta = append(c.Theta, floatOrNaN(a.Theta))
+	}
+}
+
+func floatOrNaN(v *float64) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return *v
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// OptionChain is every strike of one expiry as of a point in time, calls
+// and puts side by side. Staleness is seconds between a quote and the
+// as-of time; strikes missing one side carry nulls there.
+type OptionChain struct {
+	Spot      float64   `json:"spot"`
+	SpotTs    time.Time `json:"spot_ts"`
+	AtmStrike uint32    `json:"atm_strike"`
+
+	Strike      []uint32    `json:"strike"`
+	CeLtp       FloatColumn `json:"ce_ltp"`
+	CeTs        TimeColumn  `json:"ce_ts"`
+	CeStaleness FloatColumn `json:"ce_staleness"`
+	PeLtp       FloatColumn `json:"pe_ltp"`
+	PeTs        TimeColumn  `json:"pe_ts"`
+	PeStaleness FloatColumn `json:"pe_staleness"`
+}
@@ -14,6 +14,8 @@ type OptionContractRow struct {
 	Moneyness    string
 	MoneynessLvl int16
 	DaysToExpiry int16
+
+	OptionAnalytics
 }
 
 type OptionContractColumnar struct {
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// OptionExpiries is the listed expiries of an underlying, nearest first,
+// with one column entry per expiry. HolidayShifted is null where the
+// underlying has no expiry weekday schedule.
+type OptionExpiries struct {
+	Expiries       []string    `json:"expiries"`
+	Kind           []string    `json:"kind"` // weekly | monthly
+	FirstTs        []time.Time `json:"first_ts"`
+	LastTs         []time.Time `json:"last_ts"`
+	StrikeMin      []uint32    `json:"strike_min"`
+	StrikeMax      []uint32    `json:"strike_max"`
+	StrikeCount    []uint64    `json:"strike_count"`
+	StrikeStep     []uint32    `json:"strike_step"` // 0 with a single strike
+	HolidayShifted []*bool     `json:"holiday_shifted"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// OptionRollingRaw is the tick series of a rolling moneyness slice. Rolled
+// marks the first tick on a new strike or expiry.
+type OptionRollingRaw struct {
+	Ts     []time.Time `json:"ts"`
+	Expiry []string    `json:"expiry"`
+	Strike []uint32    `json:"strike"`
+	Ltp    FloatColumn `json:"ltp"`
+	Spot   FloatColumn `json:"spot"`
+	Rolled []bool      `json:"rolled"`
+}
+
+// OptionRollingOHLC is the candle series of a rolling moneyness slice.
+// Expiry and strike are the contract held at the bucket close; rolled
+// marks buckets that moved to a new contract, inside the bucket or since
+// the previous one.
+type OptionRollingOHLC struct {
+	Ts     []time.Time `json:"ts"`
+	Open   FloatColumn `json:"open"`
+	High   FloatColumn `json:"high"`
+	Low    FloatColumn `json:"low"`
+	Close  FloatColumn `json:"close"`
+	Expiry []string    `json:"expiry"`
+	Strike []uint32    `json:"strike"`
+	Rolled []bool      `json:"rolled"`
+
+	Filled  []bool `json:"filled,omitempty"`
+	Partial []bool `json:"partial,omitempty"`
+}
+
+// OptionPremiumSeries is the candle series of the strike priced closest
+// to a target premium, re-selected per bucket or at set times. Switches
+// lists every re-selection that changed the contract, the first choice
+// included.
+type OptionPremiumSeries struct {
+	OptionRollingOHLC
+	Switches []PremiumSwitch `json:"switches"`
+}
+
+// PremiumSwitch is one change of contract in a premium series. Premium is
+// the new strike's last price when it was selected.
+type PremiumSwitch struct {
+	Ts         string  `json:"ts"`
+	FromExpiry string  `json:"from_expiry,omitempty"`
+	FromStrike uint32  `json:"from_strike,omitempty"`
+	Expiry     string  `json:"expiry"`
+	ToStrike   uint32  `json:"to_strike"`
+	Premium    float64 `json:"premium"`
+}
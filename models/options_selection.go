@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ContractSelection is one contract per option type per trading day,
+// chosen at a fixed time of day. Reason names the rule that separated
+// the choice from the runner-up.
+type ContractSelection struct {
+	Date           []string    `json:"date"`
+	OptionType     []string    `json:"option_type"`
+	Expiry         []string    `json:"expiry"`
+	Strike         []uint32    `json:"strike"`
+	Ltp            FloatColumn `json:"ltp"`
+	LtpTs          []time.Time `json:"ltp_ts"`
+	Spot           FloatColumn `json:"spot"`
+	AtmStrike      []uint32    `json:"atm_strike"`
+	Moneyness      []string    `json:"moneyness"`
+	MoneynessLvl   []int       `json:"moneyness_lvl"`
+	PremiumGap     FloatColumn `json:"premium_gap"`
+	Candidates     []int       `json:"candidates"`
+	Reason         []string    `json:"reason"`
+	RunnerUpStrike []uint32    `json:"runner_up_strike"`
+	RunnerUpGap    FloatColumn `json:"runner_up_gap"`
+
+	Skipped []SkippedSession `json:"skipped,omitempty"`
+}
+
+// DeltaSelection is the strikes of one expiry whose delta is nearest a
+// target, as of a point in time, ranked per option type. Delta is signed,
+// negative for puts; DeltaGap is its distance from the signed target.
+type DeltaSelection struct {
+	Expiry       string    `json:"expiry"`
+	DaysToExpiry float64   `json:"days_to_expiry"`
+	Spot         float64   `json:"spot"`
+	SpotTs       time.Time `json:"spot_ts"`
+	AtmStrike    uint32    `json:"atm_strike"`
+	Candidates   int       `json:"candidates"` // quotes whose IV solved
+
+	OptionType []string    `json:"option_type"`
+	Rank       []int       `json:"rank"`
+	Strike     []uint32    `json:"strike"`
+	Ltp        FloatColumn `json:"ltp"`
+	LtpTs      []time.Time `json:"ltp_ts"`
+	IV         FloatColumn `json:"iv"`
+	Delta      FloatColumn `json:"delta"`
+	DeltaGap   FloatColumn `json:"delta_gap"`
+}
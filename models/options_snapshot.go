@@ -17,6 +17,8 @@ type OptionSnapshot struct {
 	Moneyness    string
 	MoneynessLvl int16
 	DaysToExpiry int16
+
+	OptionAnalytics
 }
 
 func (o OptionSnapshot) MarshalJSON() ([]byte, error) {
@@ -14,4 +14,6 @@ type OptionSnapshotColumnar struct {
 	Moneyness    []string    `json:"moneyness"`
 	MoneynessLvl []int16     `json:"moneyness_lvl"`
 	DaysToExpiry []int16     `json:"days_to_expiry"`
+
+	OptionAnalyticsColumns
 }
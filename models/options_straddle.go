@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// StraddleColumnar is the combined CE + PE premium per second, each leg
+// as-of filled from its last print in the session.
+type StraddleColumnar struct {
+	Ts       []time.Time `json:"ts"`
+	Premium  FloatColumn `json:"premium"`
+	CeLtp    FloatColumn `json:"ce_ltp"`
+	PeLtp    FloatColumn `json:"pe_ltp"`
+	CeStrike []uint32    `json:"ce_strike"`
+	PeStrike []uint32    `json:"pe_strike"`
+	Spot     FloatColumn `json:"spot"`
+}
@@ -6,17 +6,45 @@ type Response[T any] struct {
 }
 
 type Meta struct {
-	Underlying string `json:"underlying,omitempty"`
-	Series     string `json:"series,omitempty"`
-	Expiry     string `json:"expiry,omitempty"`
-	Strike     uint32 `json:"strike,omitempty"`
-	OptionType string `json:"option_type,omitempty"`
+	Underlying   string `json:"underlying,omitempty"`
+	Exchange     string `json:"exchange,omitempty"`
+	Series       string `json:"series,omitempty"`
+	Expiry       string `json:"expiry,omitempty"`
+	Strike       uint32 `json:"strike,omitempty"`
+	OptionType   string `json:"option_type,omitempty"`
+	Mode         string `json:"mode,omitempty"`
+	Moneyness    string `json:"moneyness,omitempty"`
+	MoneynessLvl int    `json:"moneyness_lvl,omitempty"`
 
+	At     string `json:"at,omitempty"`
 	From   string `json:"from,omitempty"`
 	To     string `json:"to,omitempty"`
 	Tf     string `json:"tf,omitempty"`
 	Offset int64  `json:"offset,omitempty"`
+	Fill   string `json:"fill,omitempty"`
+
+	Label   string   `json:"label,omitempty"`
+	Closed  string   `json:"closed,omitempty"`
+	Partial string   `json:"partial,omitempty"`
+	Aggs    []string `json:"aggs,omitempty"`
+
+	Bars    string `json:"bars,omitempty"`
+	BarSize string `json:"bar_size,omitempty"`
+
+	Tenor int `json:"tenor,omitempty"`
+
+	Roll   string        `json:"roll,omitempty"`
+	Adjust string        `json:"adjust,omitempty"`
+	Rolls  []FuturesRoll `json:"rolls,omitempty"`
 
 	FirstTs string `json:"first_ts,omitempty"`
 	LastTs  string `json:"last_ts,omitempty"`
+
+	Sessions        int              `json:"sessions,omitempty"`
+	SkippedSessions []SkippedSession `json:"skipped_sessions,omitempty"`
+}
+
+type SkippedSession struct {
+	Date   string `json:"date"`
+	Reason string `json:"reason"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// StrategyPnL is the mark-to-market P&L of a multi-leg strategy on the
+// union of its legs' timestamps.
+type StrategyPnL struct {
+	Ts         []time.Time      `json:"ts"`
+	Pnl        FloatColumn      `json:"pnl"`
+	NetPremium FloatColumn      `json:"net_premium"`
+	Legs       []StrategyLegPnL `json:"legs"`
+}
+
+// StrategyLegPnL is one leg of a strategy: the contract it resolved to,
+// its fills and its price and P&L columns, aligned with StrategyPnL.Ts.
+type StrategyLegPnL struct {
+	Leg        int    `json:"leg"`
+	Underlying string `json:"underlying"`
+	Instrument string `json:"instrument"`
+	Expiry     string `json:"expiry,omitempty"`
+	Series     string `json:"series,omitempty"`
+	Strike     uint32 `json:"strike,omitempty"`
+	Side       string `json:"side"`
+	Quantity   int    `json:"quantity"`
+	Status     string `json:"status"`
+
+	EntryTs    string   `json:"entry_ts,omitempty"`
+	EntryPrice *float64 `json:"entry_price,omitempty"`
+	ExitTs     string   `json:"exit_ts,omitempty"`
+	ExitPrice  *float64 `json:"exit_price,omitempty"`
+
+	Price FloatColumn `json:"price"`
+	Pnl   FloatColumn `json:"pnl"`
+}
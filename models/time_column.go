@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// TimeColumn is a timestamp column that encodes the zero time as JSON
+// null, for buckets that have no tick to point at.
+type TimeColumn []time.Time
+
+func (c TimeColumn) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+
+	b := make([]byte, 0, len(c)*28+2)
+	b = append(b, '[')
+
+	for i, t := range c {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if t.IsZero() {
+			b = append(b, "null"...)
+			continue
+		}
+		b = strconv.AppendQuote(b, t.Format(time.RFC3339Nano))
+	}
+
+	return append(b, ']'), nil
+}
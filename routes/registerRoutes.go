@@ -32,12 +32,78 @@ func RegisterRoutes() []apiRoute {
 			Handler: controllers.GetOptionContract,
 		},
 
+		{
+			Path:    "/options/chain",
+			Method:  "GET",
+			Handler: controllers.GetOptionChain,
+		},
+
+		{
+			Path:    "/options/iv/smile",
+			Method:  "GET",
+			Handler: controllers.GetIVSmile,
+		},
+
+		{
+			Path:    "/options/iv/surface",
+			Method:  "GET",
+			Handler: controllers.GetIVSurface,
+		},
+
+		{
+			Path:    "/options/iv/term",
+			Method:  "GET",
+			Handler: controllers.GetIVTermStructure,
+		},
+
+		{
+			Path:    "/options/rolling",
+			Method:  "GET",
+			Handler: controllers.GetOptionRolling,
+		},
+
+		{
+			Path:    "/options/straddle",
+			Method:  "GET",
+			Handler: controllers.GetStraddle,
+		},
+
+		{
+			Path:    "/options/contracts/by-delta",
+			Method:  "GET",
+			Handler: controllers.GetOptionContractsByDelta,
+		},
+
+		{
+			Path:    "/options/contracts/by-premium/daily",
+			Method:  "GET",
+			Handler: controllers.GetDailyPremiumSelection,
+		},
+
 		{
 			Path:    "/options/contracts/by-premium",
 			Method:  "GET",
 			Handler: controllers.GetOptionContractsByPremium,
 		},
 
+		{
+			Path:    "/strategy/pnl",
+			Method:  "POST",
+			Handler: controllers.GetStrategyPnL,
+		},
+
+		{
+			Path:    "/backtest",
+			Method:  "POST",
+			Handler: controllers.RunBacktest,
+		},
+
+		{
+			Path:    "/execution/fills",
+			Method:  "POST",
+			Handler: controllers.SimulateFills,
+		},
+
 		{
 			Path:    "/index/data",
 			Method:  "GET",
@@ -49,5 +115,11 @@ func RegisterRoutes() []apiRoute {
 			Method:  "GET",
 			Handler: controllers.GetFuturesData,
 		},
+
+		{
+			Path:    "/futures/basis",
+			Method:  "GET",
+			Handler: controllers.GetFuturesBasis,
+		},
 	}
 }